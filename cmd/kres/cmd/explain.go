@@ -0,0 +1,158 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/dag"
+	"github.com/siderolabs/kres/internal/explain"
+	"github.com/siderolabs/kres/internal/project/auto"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [kind[/name]]",
+	Short: "Document the .kres.yaml configuration kinds.",
+	Long: `Usage: kres explain [kind[/name]]
+
+	Without arguments, list the configuration kinds of the project in the current directory
+	with the names of the project nodes.
+
+	With a kind, e.g. golang.Toolchain, print the fields of the kind with yaml names, types,
+	documentation and the defaults set by kres (taken from the first node of the kind).
+
+	With a kind and a node name, e.g. common.Image/image-kres, print the defaults of the node
+	and the effective values after loading .kres.yaml.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(_ *cobra.Command, args []string) error {
+		var arg string
+
+		if len(args) > 0 {
+			arg = args[0]
+		}
+
+		return runExplain(os.Stdout, arg)
+	},
+}
+
+// runExplain prints the documentation of the kind (or the list of kinds if arg is empty).
+//
+//nolint:gocyclo,cyclop
+func runExplain(w io.Writer, arg string) error {
+	options, proj, err := buildProject()
+	if err != nil {
+		return err
+	}
+
+	var nodes []dag.Node
+
+	defaults := map[dag.Node]map[string]string{}
+
+	if err = dag.Walk(proj, func(node dag.Node) error {
+		nodes = append(nodes, node)
+		defaults[node] = explain.Values(node)
+
+		return nil
+	}, map[dag.Node]struct{}{}, -1); err != nil {
+		return err
+	}
+
+	if err = proj.LoadConfig(options.Config); err != nil {
+		return err
+	}
+
+	names := map[string][]string{}
+
+	for _, node := range nodes {
+		kind := config.Kind(reflect.TypeOf(node))
+
+		names[kind] = append(names[kind], node.Name())
+	}
+
+	kinds := map[string]any{}
+
+	for _, obj := range auto.ConfigKinds() {
+		kind := config.Kind(reflect.TypeOf(obj))
+
+		if kinded, ok := obj.(interface{ ConfigKind() string }); ok {
+			kind = kinded.ConfigKind()
+		}
+
+		kinds[kind] = obj
+	}
+
+	if arg == "" {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+		fmt.Fprintln(tw, "KIND\tNAMES") //nolint:errcheck
+
+		for _, kind := range slices.Sorted(maps.Keys(kinds)) {
+			_, isNode := kinds[kind].(dag.Node)
+
+			switch {
+			case !isNode:
+				fmt.Fprintf(tw, "%s\t-\n", kind) //nolint:errcheck
+			case len(names[kind]) > 0:
+				fmt.Fprintf(tw, "%s\t%s\n", kind, strings.Join(names[kind], ", ")) //nolint:errcheck
+			}
+		}
+
+		return tw.Flush()
+	}
+
+	kind, name, _ := strings.Cut(arg, "/")
+
+	obj, ok := kinds[kind]
+	if !ok {
+		return fmt.Errorf("unknown kind %q, run `kres explain` to list the kinds", kind)
+	}
+
+	if _, isNode := obj.(dag.Node); !isNode {
+		if name != "" {
+			return fmt.Errorf("kind %q doesn't support names", kind)
+		}
+
+		// builder settings are not part of the graph, load them into an empty object
+		value := reflect.New(reflect.TypeOf(obj).Elem()).Interface()
+
+		if reflect.TypeOf(obj).Elem().Kind() == reflect.Struct {
+			if err = options.Config.Load(value); err != nil {
+				return err
+			}
+		}
+
+		return explain.Write(w, kind, obj, nil, nil, explain.Values(value))
+	}
+
+	idx := slices.IndexFunc(nodes, func(node dag.Node) bool {
+		return config.Kind(reflect.TypeOf(node)) == kind && (name == "" || node.Name() == name)
+	})
+
+	if idx == -1 {
+		if name == "" {
+			return explain.Write(w, kind, obj, nil, nil, nil)
+		}
+
+		return fmt.Errorf("node %q of kind %q not found, available names: %s", name, kind, strings.Join(names[kind], ", "))
+	}
+
+	var values map[string]string
+
+	if name != "" {
+		values = explain.Values(nodes[idx])
+	}
+
+	return explain.Write(w, kind, obj, names[kind], defaults[nodes[idx]], values)
+}
@@ -0,0 +1,48 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmd_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/cmd/kres/cmd"
+)
+
+func TestRunExplain(t *testing.T) {
+	writeFixture(t, map[string]string{
+		"go.mod":              "module example.com/example\n\ngo 1.26\n",
+		"cmd/example/main.go": "package main\n\nfunc main() {}\n",
+		".kres.yaml":          "kind: common.Image\nname: image-example\nspec:\n  pushLatest: false\n  additionalImages: [fhs]\n",
+	})
+
+	var out strings.Builder
+
+	require.NoError(t, cmd.RunExplain(&out, ""))
+	assert.Contains(t, out.String(), "auto.CI                -\n")
+	assert.Contains(t, out.String(), "common.Image           image-example\n")
+	assert.Contains(t, out.String(), "golang.Toolchain       base\n")
+	assert.NotContains(t, out.String(), "js.Toolchain")
+
+	out.Reset()
+
+	require.NoError(t, cmd.RunExplain(&out, "common.Image"))
+	assert.Contains(t, out.String(), "KIND: common.Image\nNAMES: image-example\n\nDESCRIPTION:\n  Image provides common image build target.\n")
+	assert.Contains(t, out.String(), "  additionalImages <[]string>\n      default: [fhs, ca-certificates]\n  copyFrom <[]object>\n    name <string>\n")
+	assert.NotContains(t, out.String(), "value:")
+
+	out.Reset()
+
+	require.NoError(t, cmd.RunExplain(&out, "common.Image/image-example"))
+	assert.Contains(t, out.String(), "  additionalImages <[]string>\n      default: [fhs, ca-certificates]\n      value: [fhs]\n")
+	assert.Contains(t, out.String(), "  pushLatest <bool>\n      default: true\n      value: false\n")
+
+	assert.EqualError(t, cmd.RunExplain(&out, "golang.Toolchian"), "unknown kind \"golang.Toolchian\", run `kres explain` to list the kinds")
+	assert.EqualError(t, cmd.RunExplain(&out, "common.Image/image-foo"), `node "image-foo" of kind "common.Image" not found, available names: image-example`)
+	assert.EqualError(t, cmd.RunExplain(&out, "auto.CI/ci"), `kind "auto.CI" doesn't support names`)
+}
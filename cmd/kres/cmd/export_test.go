@@ -0,0 +1,22 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmd
+
+import (
+	"io"
+
+	"github.com/siderolabs/kres/internal/output"
+)
+
+// RunGen is exposed for external tests.
+var RunGen = runGen
+
+// RunInit is exposed for external tests.
+var RunInit = func(fsys output.FS, w io.Writer, types []string, module string, commands ...string) error {
+	return runInit(fsys, w, initOptions{types: types, module: module, commands: commands})
+}
+
+// RunExplain is exposed for external tests.
+var RunExplain = runExplain
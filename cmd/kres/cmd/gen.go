@@ -6,28 +6,16 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/siderolabs/kres/internal/config"
 	"github.com/siderolabs/kres/internal/output"
-	"github.com/siderolabs/kres/internal/output/codecov"
-	"github.com/siderolabs/kres/internal/output/conform"
-	"github.com/siderolabs/kres/internal/output/dockerfile"
-	"github.com/siderolabs/kres/internal/output/dockerignore"
-	"github.com/siderolabs/kres/internal/output/ghworkflow"
 	"github.com/siderolabs/kres/internal/output/gitattributes"
-	"github.com/siderolabs/kres/internal/output/github"
-	"github.com/siderolabs/kres/internal/output/gitignore"
-	"github.com/siderolabs/kres/internal/output/golangci"
-	"github.com/siderolabs/kres/internal/output/lefthook"
-	"github.com/siderolabs/kres/internal/output/license"
-	"github.com/siderolabs/kres/internal/output/makefile"
-	"github.com/siderolabs/kres/internal/output/markdownlint"
-	"github.com/siderolabs/kres/internal/output/release"
-	"github.com/siderolabs/kres/internal/output/renovate"
-	"github.com/siderolabs/kres/internal/output/sops"
-	"github.com/siderolabs/kres/internal/output/template"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
+	"github.com/siderolabs/kres/internal/project"
 	"github.com/siderolabs/kres/internal/project/auto"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -42,82 +30,127 @@ var genCmd = &cobra.Command{
 	build instructions in the following formats:
 
 	  * Makefile
-	  * Dockerfile`,
+	  * Dockerfile
+
+	With --check, no files are written: the drift between the generated files and the files
+	on disk is printed as a unified diff, and the command fails if any file is out of date.`,
 	Args: cobra.NoArgs,
-	RunE: func(*cobra.Command, []string) error {
-		return runGen()
-	},
-}
+	RunE: func(c *cobra.Command, _ []string) error {
+		fmt.Println("gen started")
 
-func runGen() error {
-	fmt.Println("gen started")
+		var (
+			fsys    = output.OSFS(".")
+			checker *output.Checker
+		)
 
-	var err error
+		if genCmdFlags.check {
+			checker = output.NewChecker(fsys, os.Stdout)
+			fsys = checker
+		}
 
-	options := meta.Options{
-		GoContainerVersion:     fmt.Sprintf("%s-alpine", config.GoVersion),
-		ContainerImageFrontend: config.ContainerImageFrontendDockerfile,
-	}
+		if err := runGen(fsys, genCmdFlags.check); err != nil {
+			return err
+		}
 
-	options.Config, err = config.NewProvider(".kres.yaml")
-	if err != nil {
-		return err
-	}
+		if checker != nil {
+			if drifted := checker.Drifted(); len(drifted) > 0 {
+				// the drift is an expected failure, the diff is already printed, and the error is printed by Execute
+				c.SilenceUsage = true
+				c.SilenceErrors = true
 
-	proj, err := auto.Build(&options)
+				return fmt.Errorf("generated files are out of date: %s", strings.Join(drifted, ", "))
+			}
+		}
+
+		fmt.Println("success")
+
+		return nil
+	},
+}
+
+var genCmdFlags struct {
+	check bool
+}
+
+func init() {
+	genCmd.Flags().BoolVar(&genCmdFlags.check, "check", false, "report drift of the generated files without writing them")
+}
+
+// runGen generates the project in the current directory, writing the output files to fsys.
+//
+// With githubDryRun, the GitHub API changes are only reported.
+func runGen(fsys output.FS, githubDryRun bool) error {
+	options, proj, err := loadProject()
 	if err != nil {
 		return err
 	}
 
-	if err := proj.LoadConfig(options.Config); err != nil {
-		return err
+	env := outputEnv{
+		options:       options,
+		gitattributes: gitattributes.NewOutput(),
+		githubDryRun:  githubDryRun,
 	}
 
-	gitattributesOutput := gitattributes.NewOutput()
+	var outputs []output.Writer
 
-	outputs := []output.Writer{
-		output.Wrap(gitattributes.Manage(gitattributesOutput, github.NewOutput())),
-		output.Wrap(gitattributes.Manage(gitattributesOutput, sops.NewOutput())),
-		output.Wrap(gitattributes.Manage(gitattributesOutput, renovate.NewOutput())),
-		output.Wrap(gitattributes.Manage(gitattributesOutput, conform.NewOutput())),
+	// the nodes compiled to GitHub Actions workflows only would be silently dropped from the GitLab CI pipeline
+	if options.CIProvider == meta.CIProviderGitLab {
+		outputs = append(outputs, gitlabci.Guard())
 	}
 
-	if !options.CompileGithubWorkflowsOnly {
-		outputs = append(
-			outputs,
-			output.Wrap(gitattributes.Manage(gitattributesOutput, dockerfile.NewOutput())),
-			output.Wrap(gitattributes.Manage(gitattributesOutput, dockerignore.NewOutput())),
-			output.Wrap(gitattributes.Manage(gitattributesOutput, makefile.NewOutput())),
-			output.Wrap(gitattributes.Manage(gitattributesOutput, golangci.NewOutput())),
-			output.Wrap(gitattributes.Manage(gitattributesOutput, license.NewOutput())),
-			output.Wrap(gitattributes.Manage(gitattributesOutput, gitignore.NewOutput())),
-			output.Wrap(gitattributes.Manage(gitattributesOutput, codecov.NewOutput())),
-			output.Wrap(gitattributes.Manage(gitattributesOutput, release.NewOutput())),
-			output.Wrap(gitattributes.Manage(gitattributesOutput, markdownlint.NewOutput())),
-			output.Wrap(gitattributes.Manage(gitattributesOutput, template.NewOutput())),
-			output.Wrap(gitattributes.Manage(gitattributesOutput, lefthook.NewOutput())),
-		)
+	for _, out := range projectOutputs {
+		if out.enabled(options) {
+			outputs = append(outputs, out.create(env))
+		}
 	}
 
-	outputs = append(outputs, output.Wrap(gitattributes.Manage(gitattributesOutput, ghworkflow.NewOutput(
-		options.MainBranch,
-		!options.CompileGithubWorkflowsOnly,
-		!options.SkipStaleWorkflow,
-		options.CIFailureSlackNotifyChannel,
-	))))
-	outputs = append(outputs, output.Wrap(gitattributes.Manage(gitattributesOutput, gitattributesOutput)))
-
 	if err := proj.Compile(outputs); err != nil {
 		return err
 	}
 
 	for _, out := range outputs {
-		if err := out.Generate(); err != nil {
+		if err := out.Generate(fsys); err != nil {
 			return fmt.Errorf("failed on step '%T', error: %w", out, err)
 		}
 	}
 
-	fmt.Println("success")
-
 	return nil
 }
+
+// loadProject detects the project in the current directory and loads .kres.yaml into it.
+func loadProject() (*meta.Options, *project.Contents, error) {
+	options, proj, err := buildProject()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := proj.LoadConfig(options.Config); err != nil {
+		return nil, nil, err
+	}
+
+	return options, proj, nil
+}
+
+// buildProject detects the project in the current directory, the nodes keep the defaults until the config is loaded.
+func buildProject() (*meta.Options, *project.Contents, error) {
+	var err error
+
+	options := &meta.Options{
+		GoContainerVersion:     fmt.Sprintf("%s-alpine", config.GoVersion),
+		ContainerImageFrontend: config.ContainerImageFrontendDockerfile,
+	}
+
+	options.Config, err = config.NewProvider(".kres.yaml")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	options.Config.SetMetadata(options)
+
+	proj, err := auto.Build(options)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return options, proj, nil
+}
@@ -0,0 +1,506 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmd_test
+
+import (
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/cmd/kres/cmd"
+	"github.com/siderolabs/kres/internal/output"
+)
+
+// writeFixture lays out the files in a temporary directory and changes into it.
+func writeFixture(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, contents := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+
+	t.Chdir(root)
+}
+
+func TestRunGen(t *testing.T) {
+	for _, test := range []struct { //nolint:govet
+		name   string
+		files  map[string]string
+		config string
+
+		// expected snippets per generated file, nil just checks that the file is generated
+		contains    map[string][]string
+		notContains map[string][]string
+		notMatches  map[string][]string
+
+		error string
+	}{
+		{
+			name:   "default",
+			config: "kind: golang.Toolchain\nspec:\n  extraPackages: [jq]\n",
+			contains: map[string][]string{
+				".dockerignore":             nil,
+				".gitattributes":            nil,
+				".github/workflows/ci.yaml": nil,
+				".golangci.yml":             nil,
+				"Dockerfile":                nil,
+				"Makefile":                  {"example-linux-amd64:"},
+				"hack/release.toml":         nil,
+			},
+		},
+		{
+			name:   "unknown config",
+			config: "kind: golang.Toolchian\nspec:\n  extraPackages: [jq]\n---\nkind: common.Image\nname: image-foo\nspec:\n  pushLatest: false\n",
+			error: ".kres.yaml:1: config block golang.Toolchian doesn't match any project node\n" +
+				".kres.yaml:5: config block common.Image/image-foo doesn't match any project node",
+		},
+		{
+			name: "overrides",
+			config: `kind: kres.Overrides
+spec:
+  disable:
+    - lint-gofumpt
+    - common.MakeHelp/build
+  replace:
+    - lint-golangci-lint
+---
+kind: auto.CustomSteps
+spec:
+  steps:
+    - name: lint-golangci-lint
+---
+kind: custom.Step
+name: lint-golangci-lint
+spec:
+  makefile:
+    enabled: true
+    script:
+      - golangci-lint run --config .golangci.custom.yml
+`,
+			contains: map[string][]string{
+				"Makefile": {
+					"lint-golangci-lint:\n\tgolangci-lint run --config .golangci.custom.yml\n",
+					"example-linux-amd64:",
+				},
+			},
+			notContains: map[string][]string{
+				"Makefile": {"lint-gofumpt", "help:"},
+			},
+		},
+		{
+			name:   "overrides disable unit-tests",
+			config: "kind: kres.Overrides\nspec:\n  disable: [unit-tests]\n",
+			notContains: map[string][]string{
+				".github/workflows/ci.yaml": {"unit-tests", "codecov"},
+			},
+		},
+		{
+			name:   "overrides node not found",
+			config: "kind: kres.Overrides\nspec:\n  disable: [lint-foo]\n",
+			error:  `kres.Overrides: node "lint-foo" not found`,
+		},
+		{
+			name:   "overrides ambiguous node",
+			config: "kind: kres.Overrides\nspec:\n  disable: [build]\n",
+			error:  `kres.Overrides: node name "build" is ambiguous, use one of: common.Build/build, common.MakeHelp/build`,
+		},
+		{
+			name:   "overrides no custom step",
+			config: "kind: kres.Overrides\nspec:\n  replace: [lint-gofumpt]\n",
+			error:  `kres.Overrides: custom step "lint-gofumpt" replacing the node is not defined`,
+		},
+		{
+			name:   "overrides config of dropped node",
+			config: "kind: kres.Overrides\nspec:\n  disable: [lint-gofumpt]\n---\nkind: golang.Gofumpt\nspec:\n  goVersion: \"1.26\"\n",
+			error:  ".kres.yaml:5: config block golang.Gofumpt doesn't match any project node",
+		},
+		{
+			name: "workspace",
+			files: map[string]string{
+				"go.work":        "go 1.26\n\nuse (\n\t.\n\t./api\n)\n",
+				"go.work.sum":    "",
+				"api/go.mod":     "module example.com/example/api\n\ngo 1.26\n",
+				"api/pkg/api.go": "package pkg\n",
+			},
+			contains: map[string][]string{
+				"Dockerfile": {
+					"COPY go.work go.work\nCOPY go.work.sum go.work.sum\nRUN --mount=type=cache,target=/go/pkg,id=/go/pkg go mod download\n",
+					"golangci-lint run --config .golangci.yml ./... ./api/...\n",
+					"-coverpkg=$(echo ${TESTPKGS} | tr ' ' ,) ${TESTPKGS}\n",
+					"FROM base AS lint-gofumpt-api\n",
+				},
+				"Makefile":      {"TESTPKGS ?= ./... ./api/...\n"},
+				".dockerignore": {"!go.work\n!go.work.sum\n"},
+			},
+			notContains: map[string][]string{
+				"Dockerfile": {"RUN cd ", "lint-golangci-lint-api", "unit-tests-api"},
+			},
+		},
+		{
+			name: "workspace module not used",
+			files: map[string]string{
+				"go.work":        "go 1.26\n\nuse .\n",
+				"api/go.mod":     "module example.com/example/api\n\ngo 1.26\n",
+				"api/pkg/api.go": "package pkg\n",
+			},
+			error: `module "api" is not used in go.work, add it to go.work or ignore it with .kresignore`,
+		},
+		{
+			name: "workspace module ignored",
+			files: map[string]string{
+				"go.work":         "go 1.26\n\nuse .\n",
+				"api/go.mod":      "module example.com/example/api\n\ngo 1.26\n",
+				"api/pkg/api.go":  "package pkg\n",
+				"api/.kresignore": "",
+			},
+			notContains: map[string][]string{
+				"Dockerfile": {"lint-gofumpt-api", "./api/..."},
+			},
+		},
+		{
+			name: "workspace local replace",
+			files: map[string]string{
+				"go.work":                   "go 1.26\n\nuse .\n\nreplace example.com/x => ./third_party/x\n",
+				"third_party/x/go.mod":      "module example.com/x\n\ngo 1.26\n",
+				"third_party/x/pkg/x/x.go":  "package x\n",
+				"third_party/x/.kresignore": "",
+			},
+			contains: map[string][]string{
+				"Dockerfile": {
+					"COPY go.work go.work\nCOPY ./third_party/x ./third_party/x\nRUN --mount=type=cache,target=/go/pkg,id=/go/pkg go mod download\n",
+				},
+				".dockerignore": {"!third_party/x\n"},
+			},
+		},
+		{
+			name: "workspace local replace outside",
+			files: map[string]string{
+				"go.work": "go 1.26\n\nuse .\n\nreplace example.com/x => ../x\n",
+			},
+			error: "go.work: replace example.com/x => ../x points outside of the project",
+		},
+		{
+			name: "local replace",
+			files: map[string]string{
+				"go.mod":                     "module example.com/example\n\ngo 1.26\n\nrequire example.com/x v0.0.0\n\nreplace example.com/x => ./third_party/x\n",
+				"third_party/x/go.mod":       "module example.com/x\n\ngo 1.26\n",
+				"third_party/x/pkg/x/x.go":   "package x\n",
+				"third_party/x/.kresignore":  "",
+				"third_party/x/testdata/foo": "",
+			},
+			contains: map[string][]string{
+				"Dockerfile": {
+					"COPY go.sum go.sum\nCOPY ./third_party/x ./third_party/x\nRUN cd .\nRUN --mount=type=cache,target=/go/pkg,id=/go/pkg go mod download\n",
+				},
+				".dockerignore": {"!third_party/x\n"},
+			},
+		},
+		{
+			name: "local replace outside",
+			files: map[string]string{
+				"go.mod": "module example.com/example\n\ngo 1.26\n\nreplace example.com/x => ../x\n",
+			},
+			error: "go.mod: replace example.com/x => ../x points outside of the project",
+		},
+		{
+			name: "sources",
+			files: map[string]string{
+				"main.go":                 "package main\n\nfunc main() {}\n",
+				"gen.go":                  "//go:build ignore\n\npackage main\n",
+				"hack/gen.go":             "// Generates stuff.\n\n//go:build ignore && linux\n\npackage main\n",
+				"tools/gen/main.go":       "package main\n\nfunc main() {}\n",
+				"legacy/legacy.go":        "package legacy\n",
+				"nested/cmd/tool/main.go": "package main\n\nfunc main() {}\n",
+			},
+			config: `kind: golang.Sources
+spec:
+  include:
+    - tools/*
+  exclude:
+    - legacy
+  commands:
+    - name: example
+      path: .
+    - name: gen
+      path: tools/gen
+    - name: tool
+      path: nested/cmd/tool
+`,
+			contains: map[string][]string{
+				"Dockerfile": {
+					"COPY ./cmd ./cmd\nCOPY ./tools/gen ./tools/gen\nCOPY ./nested/cmd/tool ./nested/cmd/tool\nCOPY ./main.go ./main.go\n",
+					"FROM base AS example-linux-amd64-build\nCOPY --from=generate / /\nWORKDIR /src\n",
+					"WORKDIR /src/tools/gen\n",
+					"WORKDIR /src/nested/cmd/tool\n",
+				},
+				".dockerignore": {"!tools/gen\n"},
+				"Makefile":      {"gen-linux-amd64:", "tool-linux-amd64:"},
+			},
+			notContains: map[string][]string{
+				"Dockerfile":    {"./hack", "./legacy", "./gen.go"},
+				".dockerignore": {"!hack\n", "!gen.go\n"},
+			},
+		},
+		{
+			name:   "sources command outside",
+			config: "kind: golang.Sources\nspec:\n  commands:\n    - name: example\n      path: ../example\n",
+			error:  `golang.Sources: command "example" path "../example" is outside of the project`,
+		},
+		{
+			name:   "sources command missing",
+			config: "kind: golang.Sources\nspec:\n  commands:\n    - name: example\n      path: tools/example\n",
+			error:  `golang.Sources: command "example" path "tools/example" is not a directory`,
+		},
+		{
+			name:   "sources command duplicate",
+			config: "kind: golang.Sources\nspec:\n  commands:\n    - name: example\n      path: .\n    - name: example\n      path: cmd/example\n",
+			error:  `golang.Sources: command "example" is defined more than once`,
+		},
+		{
+			name:   "sources invalid pattern",
+			config: "kind: golang.Sources\nspec:\n  exclude:\n    - \"[\"\n",
+			error:  `golang.Sources: invalid exclude pattern "[": syntax error in pattern`,
+		},
+		{
+			name: "fuzz",
+			files: map[string]string{
+				"pkg/parser/parser.go":             "package parser\n",
+				"pkg/parser/parser_test.go":        "package parser\n\nimport \"testing\"\n\nfunc FuzzParse(f *testing.F) {}\n\nfunc FuzzHelper() {}\n",
+				"pkg/parser/testdata/fuzz_test.go": "package testdata\n\nimport \"testing\"\n\nfunc FuzzIgnored(f *testing.F) {}\n",
+			},
+			config: "kind: golang.Fuzz\nspec:\n  fuzzTime: 5m\n  crons:\n    - '0 3 * * *'\n",
+			contains: map[string][]string{
+				"Dockerfile": {"go test -run='^$' -fuzz='^FuzzParse$' -fuzztime=${FUZZTIME} ./pkg/parser"},
+				"Makefile":   {"FUZZTIME ?= 5m\n", `--build-arg=FUZZTIME="$(FUZZTIME)"`},
+				".github/workflows/fuzz-cron.yaml": {
+					"- cron: 0 3 * * *\n",
+					"make fuzz\n",
+					"if: failure()\n",
+				},
+			},
+			notContains: map[string][]string{
+				"Dockerfile": {"FuzzHelper", "FuzzIgnored"},
+			},
+			notMatches: map[string][]string{
+				"Makefile": {"(?m)^all: .*fuzz"},
+			},
+		},
+		{
+			name: "fuzz configured",
+			files: map[string]string{
+				"pkg/parser/parser.go": "package parser\n",
+			},
+			config: "kind: golang.Fuzz\nspec:\n  targets:\n    - package: ./pkg/parser\n      name: FuzzParse\n",
+			contains: map[string][]string{
+				"Dockerfile": {"go test -run='^$' -fuzz='^FuzzParse$' -fuzztime=${FUZZTIME} ./pkg/parser"},
+			},
+		},
+		{
+			name: "benchmarks",
+			files: map[string]string{
+				"pkg/parser/parser.go":      "package parser\n",
+				"pkg/parser/parser_test.go": "package parser\n\nimport \"testing\"\n\nfunc BenchmarkParse(b *testing.B) {}\n",
+			},
+			config: "kind: golang.Benchmarks\nspec:\n  count: 10\n  compare:\n    enabled: true\n    commentOnly: true\n",
+			contains: map[string][]string{
+				"Makefile": {"BENCHCOUNT ?= 10\n", "bench-compare:"},
+				".github/workflows/ci.yaml": {
+					"  bench:\n",
+					"name: bench-baseline\n",
+					"run-id: ${{ steps.bench-baseline.outputs.result }}\n",
+					"make bench-compare\n",
+					"pull-requests: write\n",
+				},
+			},
+			notMatches: map[string][]string{
+				"Makefile": {"(?m)^all: .*bench"},
+			},
+		},
+		{
+			name: "integration tests",
+			files: map[string]string{
+				"internal/integration/integration_test.go": "//go:build integration\n\npackage integration\n",
+			},
+			config: `kind: auto.IntegrationTests
+spec:
+  tests:
+    - name: integration-test
+      path: internal/integration
+      tags:
+        - integration
+        - e2e
+      run: true
+---
+kind: golang.IntegrationTests
+name: integration-test-run
+spec:
+  services:
+    etcd:
+      image: quay.io/coreos/etcd:v3.6.0
+      healthCmd: etcdctl endpoint health
+`,
+			contains: map[string][]string{
+				"Dockerfile": {" -tags integration,e2e ", "FROM scratch AS integration-test-run-junit\n"},
+				"Makefile":   {"integration-test-run: integration-test-linux-amd64"},
+				".github/workflows/ci.yaml": {
+					"  integration-test-run:\n",
+					"image: quay.io/coreos/etcd:v3.6.0\n",
+					"make integration-test-run\n",
+					"files: _out/coverage-integration-test.txt\n",
+					"flags: integration-test\n",
+				},
+			},
+			notMatches: map[string][]string{
+				"Makefile": {"(?m)^all: .*integration-test-run"},
+			},
+		},
+		{
+			name:   "unit tests junit",
+			config: "kind: golang.UnitTests\nspec:\n  junit: true\n  verbose: true\n",
+			contains: map[string][]string{
+				"Dockerfile":                {"gotestsum --format standard-verbose --junitfile /test-reports/junit-unit-tests.xml"},
+				"Makefile":                  {`--build-arg=GOTESTSUM_VERSION="$(GOTESTSUM_VERSION)"`},
+				".github/workflows/ci.yaml": {"name: unit-tests-junit\n", "- name: test-summary\n"},
+			},
+		},
+		{
+			name:   "unit tests shards",
+			config: "kind: golang.UnitTests\nspec:\n  shards: 4\n",
+			contains: map[string][]string{
+				"Dockerfile": {"FROM scratch AS unit-tests-shard-4\n"},
+				"Makefile":   {"unit-tests-shard-4:"},
+				".github/workflows/ci.yaml": {
+					"- shard: \"4\"\n",
+					"pattern: coverage-unit-tests-shard-*\n",
+					"files: _out/coverage-unit-tests.txt\n",
+				},
+			},
+		},
+		{
+			name:   "unit tests variants",
+			config: "kind: golang.UnitTests\nspec:\n  variants:\n    - name: race\n      race: true\n      coverage: true\n      job: true\n",
+			contains: map[string][]string{
+				"Dockerfile": {"FROM scratch AS unit-tests-race\n"},
+				"Makefile":   {"unit-tests-race:"},
+				".github/workflows/ci.yaml": {
+					"  unit-tests-race:\n",
+					"files: _out/coverage-unit-tests-race.txt\n",
+					"flags: unit-tests-race\n",
+				},
+			},
+		},
+		{
+			name: "gitlab",
+			files: map[string]string{
+				"deploy/helm/example/Chart.yaml": "apiVersion: v2\nname: example\nversion: 0.1.0\n",
+			},
+			config: `kind: auto.CI
+spec:
+  provider: gitlab
+---
+kind: auto.Helm
+spec:
+  enabled: true
+  chartDir: deploy/helm/example
+  junit: true
+---
+kind: golang.UnitTests
+spec:
+  shards: 2
+  junit: true
+`,
+			contains: map[string][]string{
+				".gitlab-ci.yml": {
+					"helm:\n",
+					"- deploy/helm/**/*\n",
+					"SIGSTORE_ID_TOKEN:\n",
+					`if [ "${CI_PIPELINE_SOURCE}" = "merge_request_event" ]; then make chart-unittest; fi`,
+					`if [ -n "${CI_COMMIT_TAG}" ]; then make helm-release; fi`,
+					"- _out/helm-unittest-report.xml\n",
+					"parallel: 2\n",
+					"make unit-tests-shard-${CI_NODE_INDEX}\n",
+					"- _out/junit-unit-tests-shard-*.xml\n",
+					"codecov upload-process --disable-search --git-service gitlab --token \"${CODECOV_TOKEN}\" --flag unit-tests --file _out/coverage-unit-tests.txt\n",
+				},
+			},
+		},
+		{
+			name:   "gitlab unsupported",
+			config: "kind: auto.CI\nspec:\n  provider: gitlab\n---\nkind: common.SOPS\nspec:\n  enabled: true\n",
+			error:  "sops is not supported with gitlab CI provider",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			files := map[string]string{
+				"go.mod":              "module example.com/example\n\ngo 1.26\n",
+				"cmd/example/main.go": "package main\n\nfunc main() {}\n",
+			}
+
+			maps.Copy(files, test.files)
+
+			if test.config != "" {
+				files[".kres.yaml"] = test.config
+			}
+
+			writeFixture(t, files)
+
+			fsys := output.NewMemFS()
+
+			err := cmd.RunGen(fsys, true)
+			if test.error != "" {
+				assert.EqualError(t, err, test.error)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			readFile := func(filename string) string {
+				contents, err := fs.ReadFile(fsys, filename)
+				require.NoError(t, err)
+
+				// generated files are only written to fsys
+				_, err = os.Stat(filename)
+				assert.ErrorIs(t, err, os.ErrNotExist, filename)
+
+				return string(contents)
+			}
+
+			for filename, snippets := range test.contains {
+				contents := readFile(filename)
+
+				for _, snippet := range snippets {
+					assert.Contains(t, contents, snippet, filename)
+				}
+			}
+
+			for filename, snippets := range test.notContains {
+				contents := readFile(filename)
+
+				for _, snippet := range snippets {
+					assert.NotContains(t, contents, snippet, filename)
+				}
+			}
+
+			for filename, patterns := range test.notMatches {
+				contents := readFile(filename)
+
+				for _, pattern := range patterns {
+					assert.NotRegexp(t, pattern, contents, filename)
+				}
+			}
+		})
+	}
+}
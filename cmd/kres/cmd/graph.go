@@ -0,0 +1,60 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/siderolabs/kres/internal/project/graph"
+)
+
+var graphCmd = &cobra.Command{
+	Use:   "graph",
+	Short: "Print the project tree.",
+	Long: `Usage: kres graph [--format dot|mermaid|json]
+
+	Print the tree of the project nodes built for the current directory with .kres.yaml applied.
+	Every node is shown with its name, type and the output compilers it implements, edges
+	point from the inputs to the nodes depending on them.`,
+	Args: cobra.NoArgs,
+	RunE: func(*cobra.Command, []string) error {
+		return runGraph(os.Stdout, graphCmdFlags.format)
+	},
+}
+
+var graphCmdFlags struct {
+	format string
+}
+
+func init() {
+	graphCmd.Flags().StringVar(&graphCmdFlags.format, "format", "dot", "output format: dot, mermaid or json")
+}
+
+func runGraph(w io.Writer, format string) error {
+	_, proj, err := loadProject()
+	if err != nil {
+		return err
+	}
+
+	g, err := graph.Build(proj, projectCompilers()...)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "dot":
+		return g.WriteDOT(w)
+	case "mermaid":
+		return g.WriteMermaid(w)
+	case "json":
+		return g.WriteJSON(w)
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+}
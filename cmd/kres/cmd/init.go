@@ -0,0 +1,263 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/output"
+)
+
+// Project types supported by init.
+const (
+	initTypeGo      = "go"
+	initTypeJS      = "js"
+	initTypePkgfile = "pkgfile"
+	initTypeHelm    = "helm"
+)
+
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Scaffold a new project.",
+	Long: `Usage: kres init --type go --module github.com/org/name [--command name]...
+
+	Lay out a minimal project tree detected by kres and a starter .kres.yaml in the current
+	directory, and run the generation once. Existing files are never overwritten.
+
+	Project types (might be combined, e.g. --type go,helm):
+
+	  * go: go.mod, cmd/<command>/main.go and internal/version package
+	  * js: frontend/package.json, requires go
+	  * pkgfile: Pkgfile
+	  * helm: deploy/helm/<name> chart, requires go or pkgfile`,
+	Args: cobra.NoArgs,
+	RunE: func(*cobra.Command, []string) error {
+		fsys := output.OSFS(".")
+
+		if err := runInit(fsys, os.Stdout, initCmdFlags); err != nil {
+			return err
+		}
+
+		return runGen(fsys, false)
+	},
+}
+
+type initOptions struct {
+	module   string
+	types    []string
+	commands []string
+}
+
+var initCmdFlags initOptions
+
+func init() {
+	initCmd.Flags().StringSliceVar(&initCmdFlags.types, "type", []string{initTypeGo}, "project types: go, js, pkgfile, helm")
+	initCmd.Flags().StringVar(&initCmdFlags.module, "module", "", "Go module path, required for go projects")
+	initCmd.Flags().StringSliceVar(&initCmdFlags.commands, "command", nil, "Go command names, defaults to the last element of the module path")
+}
+
+// runInit writes the scaffold of the project to fsys, existing files are kept.
+func runInit(fsys output.FS, w io.Writer, opts initOptions) error {
+	files, err := scaffold(opts)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if _, err = fs.Stat(fsys, name); err == nil {
+			fmt.Fprintf(w, "skipped %s: already exists\n", name) //nolint:errcheck
+
+			continue
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		if err = fsys.MkdirAll(path.Dir(name), 0o755); err != nil {
+			return err
+		}
+
+		if err = fsys.WriteFile(name, []byte(files[name]), 0o644); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "created %s\n", name) //nolint:errcheck
+	}
+
+	return nil
+}
+
+// scaffold returns the files of the new project.
+//
+//nolint:gocyclo,cyclop
+func scaffold(opts initOptions) (map[string]string, error) {
+	types := map[string]bool{}
+
+	for _, typ := range opts.types {
+		switch typ {
+		case initTypeGo, initTypeJS, initTypePkgfile, initTypeHelm:
+			types[typ] = true
+		default:
+			return nil, fmt.Errorf("unsupported project type %q, expected one of go, js, pkgfile, helm", typ)
+		}
+	}
+
+	if !types[initTypeGo] && !types[initTypePkgfile] {
+		return nil, errors.New("at least one of go or pkgfile project types is required")
+	}
+
+	if types[initTypeJS] && !types[initTypeGo] {
+		return nil, errors.New("js project type requires go project type, the frontend is embedded into Go code")
+	}
+
+	if types[initTypeGo] && types[initTypePkgfile] {
+		return nil, errors.New("go and pkgfile project types are mutually exclusive")
+	}
+
+	name := projectName(opts)
+	files := map[string]string{}
+	kresConfig := []string{initConfigHeader}
+
+	if types[initTypeGo] {
+		if opts.module == "" {
+			return nil, errors.New("--module is required for go projects")
+		}
+
+		commands := opts.commands
+		if len(commands) == 0 {
+			commands = []string{path.Base(opts.module)}
+		}
+
+		files["go.mod"] = fmt.Sprintf("module %s\n\ngo %s\n", opts.module, config.GoVersion)
+		files["internal/version/data/tag"] = "undefined"
+		files["internal/version/data/sha"] = "undefined"
+
+		for _, command := range commands {
+			if command == "" || strings.ContainsAny(command, `/\`) {
+				return nil, fmt.Errorf("invalid command name %q", command)
+			}
+
+			files["cmd/"+command+"/main.go"] = fmt.Sprintf(initMainGo, output.License(output.MPLHeader, "// "), opts.module)
+		}
+
+		kresConfig = append(kresConfig, initGenerateConfig)
+	}
+
+	if types[initTypeJS] {
+		files["frontend/package.json"] = fmt.Sprintf(initPackageJSON, name)
+	}
+
+	if types[initTypePkgfile] {
+		files[config.ContainerImageFrontendPkgfile] = fmt.Sprintf(initPkgfile, config.BldrImageVersion)
+	}
+
+	if types[initTypeHelm] {
+		chartDir := "deploy/helm/" + name
+
+		files[chartDir+"/Chart.yaml"] = fmt.Sprintf(initChartYAML, name)
+		files[chartDir+"/values.yaml"] = "{}\n"
+
+		kresConfig = append(kresConfig, fmt.Sprintf(initHelmConfig, chartDir))
+	}
+
+	files[".kres.yaml"] = strings.Join(kresConfig, "---\n")
+
+	return files, nil
+}
+
+// projectName returns the name of the project, used for the chart and package names.
+func projectName(opts initOptions) string {
+	switch {
+	case len(opts.commands) > 0:
+		return opts.commands[0]
+	case opts.module != "":
+		return path.Base(opts.module)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "project"
+	}
+
+	return filepath.Base(wd)
+}
+
+const initConfigHeader = `# Configuration file for Kres
+#
+# Kres configuration is a multi-document YAML file, each document has same structure:
+#
+#   ---
+#   kind: <package>.<Type>
+#   name: <nodeName>  # (optional)
+#   spec:  # configuration for specific project node
+#      field: value
+#      ...
+#
+#
+# Any node in the tree might have its configuration overridden via the config.
+`
+
+const initGenerateConfig = `kind: golang.Generate
+spec:
+  versionPackagePath: internal/version
+`
+
+const initHelmConfig = `kind: auto.Helm
+spec:
+  enabled: true
+  chartDir: %s
+`
+
+const initMainGo = `%s
+// Package main is the entrypoint of the command.
+package main
+
+import (
+	"fmt"
+
+	"%s/internal/version"
+)
+
+func main() {
+	fmt.Println(version.Name, version.Tag, version.SHA)
+}
+`
+
+const initPackageJSON = `{
+  "name": %q,
+  "private": true,
+  "scripts": {
+    "build": "echo build",
+    "lint": "echo lint",
+    "lint:fix": "echo lint:fix",
+    "test": "echo test"
+  }
+}
+`
+
+const initPkgfile = `# syntax = ghcr.io/siderolabs/bldr:%s
+
+format: v1alpha2
+
+vars: {}
+`
+
+const initChartYAML = `apiVersion: v2
+name: %s
+type: application
+version: 0.1.0
+appVersion: "0.1.0"
+`
@@ -0,0 +1,129 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmd_test
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/cmd/kres/cmd"
+	"github.com/siderolabs/kres/internal/output"
+)
+
+func TestRunInit(t *testing.T) {
+	writeFixture(t, map[string]string{
+		"README.md": "# example\n",
+	})
+
+	fsys := output.NewMemFS()
+
+	var log strings.Builder
+
+	require.NoError(t, cmd.RunInit(fsys, &log, []string{"go", "helm"}, "example.com/org/example", "example", "exampled"))
+
+	assert.Equal(t, `created .kres.yaml
+created cmd/example/main.go
+created cmd/exampled/main.go
+created deploy/helm/example/Chart.yaml
+created deploy/helm/example/values.yaml
+created go.mod
+created internal/version/data/sha
+created internal/version/data/tag
+`, log.String())
+
+	mainGo, err := fs.ReadFile(fsys, "cmd/exampled/main.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(mainGo), `"example.com/org/example/internal/version"`)
+
+	// the scaffold is not written to disk
+	entries, err := os.ReadDir(".")
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "README.md", entries[0].Name())
+
+	// existing files are kept
+	fsys = output.NewMemFS()
+	require.NoError(t, fsys.WriteFile("go.mod", []byte("module example.com/org/example\n\ngo 1.26\n"), 0o644))
+	require.NoError(t, fsys.WriteFile(".kres.yaml", []byte("---\n"), 0o644))
+
+	log.Reset()
+
+	require.NoError(t, cmd.RunInit(fsys, &log, []string{"go"}, "example.com/org/example"))
+	assert.Contains(t, log.String(), "skipped go.mod: already exists\n")
+	assert.Contains(t, log.String(), "skipped .kres.yaml: already exists\n")
+	assert.Contains(t, log.String(), "created cmd/example/main.go\n")
+
+	kresConfig, err := fs.ReadFile(fsys, ".kres.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "---\n", string(kresConfig))
+}
+
+func TestRunInitGen(t *testing.T) {
+	writeFixture(t, nil)
+
+	require.NoError(t, cmd.RunInit(output.OSFS("."), io.Discard, []string{"go", "helm"}, "example.com/org/example", "exampled"))
+
+	fsys := output.NewMemFS()
+
+	require.NoError(t, cmd.RunGen(fsys, true))
+
+	for _, filename := range []string{
+		"Dockerfile",
+		"Makefile",
+		"internal/version/version.go",
+	} {
+		_, err := fs.Stat(fsys, filename)
+		assert.NoError(t, err, filename)
+	}
+
+	makefile, err := fs.ReadFile(fsys, "Makefile")
+	require.NoError(t, err)
+	assert.Contains(t, string(makefile), "exampled-linux-amd64:")
+	assert.Contains(t, string(makefile), "helm:")
+}
+
+func TestRunInitInvalid(t *testing.T) {
+	writeFixture(t, nil)
+
+	for _, tc := range []struct {
+		types  []string
+		module string
+		error  string
+	}{
+		{
+			types: []string{"rust"},
+			error: `unsupported project type "rust", expected one of go, js, pkgfile, helm`,
+		},
+		{
+			types: []string{"helm"},
+			error: "at least one of go or pkgfile project types is required",
+		},
+		{
+			types: []string{"pkgfile", "js"},
+			error: "js project type requires go project type, the frontend is embedded into Go code",
+		},
+		{
+			types: []string{"go"},
+			error: "--module is required for go projects",
+		},
+		{
+			types:  []string{"go", "pkgfile"},
+			module: "example.com/example",
+			error:  "go and pkgfile project types are mutually exclusive",
+		},
+	} {
+		assert.EqualError(t, cmd.RunInit(output.NewMemFS(), io.Discard, tc.types, tc.module), tc.error)
+	}
+
+	entries, err := os.ReadDir(".")
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
@@ -0,0 +1,125 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmd
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/siderolabs/kres/internal/output"
+	"github.com/siderolabs/kres/internal/output/codecov"
+	"github.com/siderolabs/kres/internal/output/conform"
+	"github.com/siderolabs/kres/internal/output/dockerfile"
+	"github.com/siderolabs/kres/internal/output/dockerignore"
+	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitattributes"
+	"github.com/siderolabs/kres/internal/output/github"
+	"github.com/siderolabs/kres/internal/output/gitignore"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
+	"github.com/siderolabs/kres/internal/output/golangci"
+	"github.com/siderolabs/kres/internal/output/lefthook"
+	"github.com/siderolabs/kres/internal/output/license"
+	"github.com/siderolabs/kres/internal/output/makefile"
+	"github.com/siderolabs/kres/internal/output/markdownlint"
+	"github.com/siderolabs/kres/internal/output/release"
+	"github.com/siderolabs/kres/internal/output/renovate"
+	"github.com/siderolabs/kres/internal/output/sops"
+	"github.com/siderolabs/kres/internal/output/template"
+	"github.com/siderolabs/kres/internal/project/meta"
+)
+
+// outputEnv is the environment the outputs are created in.
+type outputEnv struct {
+	options       *meta.Options
+	gitattributes *gitattributes.Output
+	githubDryRun  bool
+}
+
+// projectOutput is an output compiled from the project nodes implementing its compiler interface.
+type projectOutput struct {
+	compiler reflect.Type
+	enabled  func(options *meta.Options) bool
+	create   func(env outputEnv) output.Writer
+}
+
+func newProjectOutput[T any](enabled func(options *meta.Options) bool, create func(env outputEnv) output.TypedWriter[T]) projectOutput {
+	return projectOutput{
+		compiler: reflect.TypeFor[T](),
+		enabled:  enabled,
+		create: func(env outputEnv) output.Writer {
+			return output.Wrap(gitattributes.Manage(env.gitattributes, create(env)))
+		},
+	}
+}
+
+func always(*meta.Options) bool { return true }
+
+func notGithubWorkflowsOnly(options *meta.Options) bool { return !options.CompileGithubWorkflowsOnly }
+
+// projectOutputs is the list of outputs generated by `kres gen` in the generation order.
+//
+// The gitattributes output goes last, as it collects the files generated by the other outputs.
+var projectOutputs = []projectOutput{
+	newProjectOutput(always, func(env outputEnv) output.TypedWriter[github.Compiler] {
+		return github.NewOutput(env.githubDryRun)
+	}),
+	newProjectOutput(always, func(outputEnv) output.TypedWriter[sops.Compiler] { return sops.NewOutput() }),
+	newProjectOutput(always, func(outputEnv) output.TypedWriter[renovate.Compiler] { return renovate.NewOutput() }),
+	newProjectOutput(always, func(outputEnv) output.TypedWriter[conform.Compiler] { return conform.NewOutput() }),
+	newProjectOutput(notGithubWorkflowsOnly, func(outputEnv) output.TypedWriter[dockerfile.Compiler] { return dockerfile.NewOutput() }),
+	newProjectOutput(notGithubWorkflowsOnly, func(outputEnv) output.TypedWriter[dockerignore.Compiler] { return dockerignore.NewOutput() }),
+	newProjectOutput(notGithubWorkflowsOnly, func(outputEnv) output.TypedWriter[makefile.Compiler] { return makefile.NewOutput() }),
+	newProjectOutput(notGithubWorkflowsOnly, func(outputEnv) output.TypedWriter[golangci.Compiler] { return golangci.NewOutput() }),
+	newProjectOutput(notGithubWorkflowsOnly, func(outputEnv) output.TypedWriter[license.Compiler] { return license.NewOutput() }),
+	newProjectOutput(notGithubWorkflowsOnly, func(outputEnv) output.TypedWriter[gitignore.Compiler] { return gitignore.NewOutput() }),
+	newProjectOutput(notGithubWorkflowsOnly, func(outputEnv) output.TypedWriter[codecov.Compiler] { return codecov.NewOutput() }),
+	newProjectOutput(notGithubWorkflowsOnly, func(outputEnv) output.TypedWriter[release.Compiler] { return release.NewOutput() }),
+	newProjectOutput(notGithubWorkflowsOnly, func(outputEnv) output.TypedWriter[markdownlint.Compiler] { return markdownlint.NewOutput() }),
+	newProjectOutput(notGithubWorkflowsOnly, func(outputEnv) output.TypedWriter[template.Compiler] { return template.NewOutput() }),
+	newProjectOutput(notGithubWorkflowsOnly, func(outputEnv) output.TypedWriter[lefthook.Compiler] { return lefthook.NewOutput() }),
+	newProjectOutput(
+		func(options *meta.Options) bool { return options.CIProvider == meta.CIProviderGitLab },
+		func(env outputEnv) output.TypedWriter[gitlabci.Compiler] {
+			return gitlabci.NewOutput(env.options.MainBranch)
+		},
+	),
+	newProjectOutput(
+		func(options *meta.Options) bool { return options.CIProvider != meta.CIProviderGitLab },
+		func(env outputEnv) output.TypedWriter[ghworkflow.Compiler] {
+			ghworkflowOutput := ghworkflow.NewOutput(
+				env.options.MainBranch,
+				!env.options.CompileGithubWorkflowsOnly,
+				!env.options.SkipStaleWorkflow,
+				env.options.CIFailureSlackNotifyChannel,
+			)
+
+			if env.options.CIProvider == meta.CIProviderForgejo {
+				ghworkflowOutput.SetForgejo(ghworkflow.Forgejo{
+					ActionsURL: env.options.ForgejoActionsURL,
+					Runners:    env.options.ForgejoRunners,
+				})
+			}
+
+			return ghworkflowOutput
+		},
+	),
+	newProjectOutput(always, func(env outputEnv) output.TypedWriter[gitattributes.Compiler] { return env.gitattributes }),
+}
+
+// projectCompilers returns the compiler interfaces of all outputs sorted by name.
+func projectCompilers() []reflect.Type {
+	compilers := make([]reflect.Type, 0, len(projectOutputs))
+
+	for _, out := range projectOutputs {
+		compilers = append(compilers, out.compiler)
+	}
+
+	slices.SortFunc(compilers, func(a, b reflect.Type) int {
+		return strings.Compare(a.String(), b.String())
+	})
+
+	return compilers
+}
@@ -28,6 +28,10 @@ var rootCmd = &cobra.Command{
 func init() {
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(genCmd)
+	rootCmd.AddCommand(graphCmd)
+	rootCmd.AddCommand(schemaCmd)
+	rootCmd.AddCommand(initCmd)
+	rootCmd.AddCommand(explainCmd)
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
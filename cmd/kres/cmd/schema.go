@@ -0,0 +1,37 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/project/auto"
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print JSON Schema of the .kres.yaml configuration.",
+	Long: `Usage: kres schema
+
+	Print JSON Schema of a single .kres.yaml configuration document. Each document
+	is validated against the kind, and the spec is validated against the fields of the
+	configured project node.
+
+	Editors can use the schema to validate and autocomplete .kres.yaml, e.g. with
+	yaml-language-server:
+
+	  # yaml-language-server: $schema=kres.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: func(*cobra.Command, []string) error {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		return encoder.Encode(config.Schema(auto.ConfigKinds()...))
+	},
+}
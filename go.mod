@@ -6,6 +6,7 @@ require (
 	github.com/go-git/go-git/v5 v5.19.2
 	github.com/google/go-github/v88 v88.0.0
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/siderolabs/gen v0.8.7
 	github.com/spf13/cobra v1.10.2
 	github.com/stretchr/testify v1.12.1
@@ -10,7 +10,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path"
 	"reflect"
 
 	"go.yaml.in/yaml/v4"
@@ -28,84 +27,227 @@ type Document struct {
 
 	// Spec is loaded into the matching object.
 	Spec yaml.Node `yaml:"spec"`
+
+	// location of the document in the config file.
+	path string
+	line int
+
+	// depth is the include depth of the config file, the documents of the including file override the included ones.
+	depth int
+
+	// consumed is set once the document is loaded into some object.
+	consumed bool
+
+	// interpolated is set once the references in the spec are replaced.
+	interpolated bool
+}
+
+func (doc *Document) location() string {
+	return fmt.Sprintf("%s:%d", doc.path, doc.line)
+}
+
+func (doc *Document) String() string {
+	if doc.Name == "" {
+		return doc.Kind
+	}
+
+	return doc.Kind + "/" + doc.Name
 }
 
 // Provider resolves configuration for each object.
 type Provider struct {
-	docs []Document
+	metadata         any
+	resolvedMetadata map[string]string
+	vars             Vars
+	varsDepth        map[string]int
+	docs             []Document
 }
 
 // NewProvider loads configuration file and parses it.
+//
+// The documents of [IncludeKind] are replaced with the documents of the included files,
+// and the documents with the same kind and name are deep-merged, see [Include].
 func NewProvider(path string) (*Provider, error) {
-	r, err := os.Open(path)
-	if err != nil {
+	provider := &Provider{}
+
+	if _, err := os.Stat(path); err != nil {
 		if os.IsNotExist(err) {
-			return &Provider{}, nil
+			return provider, nil
 		}
 
 		return nil, err
 	}
 
+	if err := provider.loadFile(path, nil); err != nil {
+		return provider, err
+	}
+
+	provider.mergeDocuments()
+
+	return provider, nil
+}
+
+// loadFile parses the config file appending the documents to the provider.
+func (provider *Provider) loadFile(path string, includedFrom []string) error {
+	r, err := openConfig(path)
+	if err != nil {
+		return err
+	}
+
 	defer r.Close() //nolint:errcheck
 
 	decoder := yaml.NewDecoder(r)
 
-	provider := &Provider{}
-
 	for {
-		var doc Document
+		var node yaml.Node
 
-		if err := decoder.Decode(&doc); err != nil {
+		if err := decoder.Decode(&node); err != nil {
 			if errors.Is(err, io.EOF) {
 				break
 			}
 
-			return provider, err
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		doc := Document{
+			path:  path,
+			line:  node.Line,
+			depth: len(includedFrom),
+		}
+
+		if len(node.Content) > 0 {
+			doc.line = node.Content[0].Line
+		}
+
+		if err := node.Load(&doc, yaml.WithKnownFields()); err != nil {
+			return fmt.Errorf("%s: %w", doc.location(), err)
+		}
+
+		switch doc.Kind {
+		case IncludeKind:
+			if err := provider.include(&doc, append(includedFrom, path)); err != nil {
+				return err
+			}
+
+			continue
+		case VarsKind:
+			var vars Vars
+
+			if err := doc.Spec.Load(&vars, yaml.WithKnownFields()); err != nil {
+				return fmt.Errorf("%s: error decoding config block %v: %w", doc.location(), doc, err)
+			}
+
+			if provider.vars == nil {
+				provider.vars = Vars{}
+				provider.varsDepth = map[string]int{}
+			}
+
+			for name, value := range vars {
+				// the variables of the including file override the included ones wherever the include is
+				if depth, ok := provider.varsDepth[name]; ok && depth < doc.depth {
+					continue
+				}
+
+				provider.vars[name] = value
+				provider.varsDepth[name] = doc.depth
+			}
+
+			continue
 		}
 
 		provider.docs = append(provider.docs, doc)
 	}
 
-	decoder.KnownFields(true)
-
-	return provider, nil
+	return nil
 }
 
 // Load config into passed object.
 //
 // All the matching configs are loaded in the order specified.
+// The kind might be overridden by the object with ConfigKind method.
 func (provider *Provider) Load(obj any) error {
-	type named interface {
-		Name() string
-	}
-
-	typ := reflect.TypeOf(obj).Elem()
-	kind := path.Base(typ.PkgPath()) + "." + typ.Name()
+	kind, name := kindName(obj)
 
-	name := ""
-	if namedObj, ok := obj.(named); ok {
-		name = namedObj.Name()
-	}
+	for i := range provider.docs {
+		doc := &provider.docs[i]
 
-	for _, doc := range provider.docs {
 		if doc.Kind != kind {
 			continue
 		}
 
 		if doc.Name != "" && name == "" {
-			return fmt.Errorf("config has name %v for kind %v, while object doesn't support names", doc.Name, kind)
+			return fmt.Errorf("%s: config has name %v for kind %v, while object doesn't support names", doc.location(), doc.Name, kind)
 		}
 
 		if doc.Name == "" || doc.Name == name {
+			doc.consumed = true
+
 			if doc.Spec.IsZero() {
-				return fmt.Errorf("missing spec for config block %v/%v", doc.Kind, doc.Name)
+				return fmt.Errorf("%s: missing spec for config block %v/%v", doc.location(), doc.Kind, doc.Name)
+			}
+
+			if err := provider.interpolate(doc); err != nil {
+				return err
 			}
 
-			if err := doc.Spec.Decode(obj); err != nil {
-				return fmt.Errorf("error decoding config block %v/%v into %v: %w", doc.Kind, doc.Name, obj, err)
+			if err := doc.Spec.Load(obj, yaml.WithKnownFields()); err != nil {
+				return fmt.Errorf("%s: error decoding config block %v/%v into %T: %w", doc.location(), doc.Kind, doc.Name, obj, err)
 			}
 		}
 	}
 
 	return nil
 }
+
+// Defines returns true if there is a config document for the passed object.
+//
+// The document is not marked as consumed.
+func (provider *Provider) Defines(obj any) bool {
+	kind, name := kindName(obj)
+
+	for _, doc := range provider.docs {
+		if doc.Kind == kind && (doc.Name == "" || doc.Name == name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// kindName returns the config kind and name of the object.
+func kindName(obj any) (kind, name string) {
+	type named interface {
+		Name() string
+	}
+
+	kind = Kind(reflect.TypeOf(obj))
+
+	if kinded, ok := obj.(interface{ ConfigKind() string }); ok {
+		kind = kinded.ConfigKind()
+	}
+
+	if namedObj, ok := obj.(named); ok {
+		name = namedObj.Name()
+	}
+
+	return kind, name
+}
+
+// CheckConsumed returns an error for every config document which wasn't loaded into any object.
+//
+// It catches typos in kinds and names of the configured objects.
+// The documents of the included files are shared by the projects which might lack some of the configured objects,
+// so they are only checked if they are overridden by the including file.
+func (provider *Provider) CheckConsumed() error {
+	var errs []error
+
+	for i := range provider.docs {
+		doc := &provider.docs[i]
+
+		if !doc.consumed && doc.depth == 0 {
+			errs = append(errs, fmt.Errorf("%s: config block %v doesn't match any project node", doc.location(), doc))
+		}
+	}
+
+	return errors.Join(errs...)
+}
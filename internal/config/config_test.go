@@ -20,6 +20,7 @@ func TestMissingFile(t *testing.T) {
 }
 
 type Foo struct { //nolint:govet
+	Labels   map[string]string
 	Contents string
 	Len      int
 	Extra    string
@@ -52,20 +53,183 @@ func TestLoad(t *testing.T) {
 		name: "Bad",
 	}
 	err = provider.Load(&bad)
-	require.NoError(t, err)
+	require.EqualError(t, err, "testdata/.kres.yaml:19: error decoding config block config_test.Foo/Bad into *config_test.Foo: yaml: construct errors: line 22: field field not found in type config_test.Foo")
 
 	err = provider.Load(&Other{})
-	require.EqualError(t, err, "config has name blah for kind config_test.Other, while object doesn't support names")
+	require.EqualError(t, err, "testdata/.kres.yaml:24: config has name blah for kind config_test.Other, while object doesn't support names")
 
 	reallyBad := Foo{
 		name: "ReallyBad",
 	}
 	err = provider.Load(&reallyBad)
-	require.EqualError(t, err, "error decoding config block config_test.Foo/ReallyBad into &{ 0 same ReallyBad}: yaml: construct errors: line 32: cannot construct !!str `infinite` into int")
+	require.EqualError(t, err, "testdata/.kres.yaml:29: error decoding config block config_test.Foo/ReallyBad into *config_test.Foo: yaml: construct errors: line 32: cannot construct !!str `infinite` into int")
 
 	noSpec := Foo{
 		name: "NoSpec",
 	}
 	err = provider.Load(&noSpec)
-	require.EqualError(t, err, "missing spec for config block config_test.Foo/NoSpec")
+	require.EqualError(t, err, "testdata/.kres.yaml:34: missing spec for config block config_test.Foo/NoSpec")
+
+	err = provider.CheckConsumed()
+	require.EqualError(t, err, "testdata/.kres.yaml:12: config block config_test.Bar/Bar doesn't match any project node\n"+
+		"testdata/.kres.yaml:17: config block pkg.Other doesn't match any project node\n"+
+		"testdata/.kres.yaml:24: config block config_test.Other/blah doesn't match any project node")
+}
+
+func TestUnknownDocumentField(t *testing.T) {
+	_, err := config.NewProvider("testdata/unknown-field.yaml")
+	require.EqualError(t, err, "testdata/unknown-field.yaml:6: yaml: construct errors: line 8: field specs not found in type config.Document")
+}
+
+func TestInclude(t *testing.T) {
+	provider, err := config.NewProvider("testdata/include/.kres.yaml")
+	require.NoError(t, err)
+
+	foo := Foo{
+		name: "Bar",
+	}
+
+	require.NoError(t, provider.Load(&foo))
+
+	assert.Equal(t, Foo{
+		Labels:   map[string]string{"org": "siderolabs", "team": "kres"},
+		Contents: "from-base",
+		Len:      7,
+		Extra:    "base",
+		name:     "Bar",
+	}, foo)
+
+	require.NoError(t, provider.CheckConsumed())
+}
+
+func TestIncludeLast(t *testing.T) {
+	provider, err := config.NewProvider("testdata/include/include-last.yaml")
+	require.NoError(t, err)
+
+	foo := Foo{
+		name: "Bar",
+	}
+
+	require.NoError(t, provider.Load(&foo))
+
+	// the local documents and variables override the included ones even if the include follows them
+	assert.Equal(t, Foo{
+		Labels:   map[string]string{"org": "siderolabs", "team": "kres"},
+		Contents: "from-local",
+		Len:      7,
+		Extra:    "base",
+		name:     "Bar",
+	}, foo)
+}
+
+func TestIncludeShared(t *testing.T) {
+	provider, err := config.NewProvider("testdata/include/shared.yaml")
+	require.NoError(t, err)
+
+	foo := Foo{
+		name: "Bar",
+	}
+
+	require.NoError(t, provider.Load(&foo))
+
+	assert.Equal(t, Foo{
+		Contents: "from-local",
+		Len:      3,
+		name:     "Bar",
+	}, foo)
+
+	// the included file configures a kind the project doesn't have
+	require.NoError(t, provider.CheckConsumed())
+}
+
+func TestIncludeProfile(t *testing.T) {
+	assert.Contains(t, config.Profiles(), "go-release-binaries-v1")
+
+	provider, err := config.NewProvider("testdata/include/profile.yaml")
+	require.NoError(t, err)
+
+	require.EqualError(t, provider.CheckConsumed(), "testdata/include/profile.yaml:7: config block golang.Build doesn't match any project node")
+
+	_, err = config.NewProvider("testdata/include/unknown-profile.yaml")
+	require.EqualError(t, err, `testdata/include/unknown-profile.yaml:2: unknown config profile "no-such-profile-v1", available profiles: go-release-binaries-v1`)
+}
+
+func TestIncludeCycle(t *testing.T) {
+	_, err := config.NewProvider("testdata/include/cycle.yaml")
+	require.EqualError(t, err, "testdata/include/cycle.yaml:2: include cycle: testdata/include/cycle.yaml -> testdata/include/cycle.yaml")
+}
+
+type Metadata struct {
+	GitHubOrganization string
+	GitHubRepository   string
+	MainBranch         string
+	Platforms          []string
+}
+
+type Interpolated struct {
+	Labels  map[string]string `yaml:"labels"`
+	Image   string            `yaml:"image"`
+	Branch  string            `yaml:"branch"`
+	Script  string            `yaml:"script"`
+	Enabled bool              `yaml:"enabled"`
+}
+
+type Unresolved struct {
+	Extra map[string]string `yaml:"extra"`
+	Image string            `yaml:"image"`
+	Tag   string            `yaml:"tag"`
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("KRES_TEST_ENABLED", "true")
+
+	provider, err := config.NewProvider("testdata/interpolate.yaml")
+	require.NoError(t, err)
+
+	provider.SetMetadata(&Metadata{
+		GitHubOrganization: "siderolabs",
+		GitHubRepository:   "kres",
+		MainBranch:         "main",
+		Platforms:          []string{"linux/amd64", "linux/arm64"},
+	})
+
+	var interpolated Interpolated
+
+	require.NoError(t, provider.Load(&interpolated))
+
+	assert.Equal(t, Interpolated{
+		Labels:  map[string]string{"platforms": "linux/amd64,linux/arm64"},
+		Image:   "ghcr.io/siderolabs/kres:latest",
+		Branch:  "main",
+		Script:  `echo "${{ github.sha }} ${HOME} ${env:KEEP}"`,
+		Enabled: true,
+	}, interpolated)
+
+	require.EqualError(t, provider.Load(&Unresolved{}), "testdata/interpolate.yaml:19: unresolved reference ${env:KRES_TEST_UNSET}\n"+
+		`testdata/interpolate.yaml:21: unknown metadata field "NoSuchField"`)
+}
+
+func TestInterpolateMetadataNotFinal(t *testing.T) {
+	t.Setenv("KRES_TEST_ENABLED", "true")
+
+	provider, err := config.NewProvider("testdata/interpolate.yaml")
+	require.NoError(t, err)
+
+	metadata := &Metadata{
+		GitHubOrganization: "siderolabs",
+		GitHubRepository:   "kres",
+		MainBranch:         "master",
+	}
+
+	provider.SetMetadata(metadata)
+
+	require.NoError(t, provider.Load(&Interpolated{}))
+	require.NoError(t, provider.CheckMetadata())
+
+	// the metadata is completed after the document is interpolated
+	metadata.MainBranch = "main"
+	metadata.Platforms = []string{"linux/amd64"}
+
+	require.EqualError(t, provider.CheckMetadata(), `${meta.MainBranch} was resolved to "master" before the metadata was final ("main"), it can't be referenced`+"\n"+
+		`${meta.Platforms} was resolved to "" before the metadata was final ("linux/amd64"), it can't be referenced`)
 }
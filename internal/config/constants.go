@@ -14,6 +14,13 @@ const (
 	// renovate: datasource=github-releases depName=siderolabs/bldr
 	BldrImageVersion = "v0.6.3"
 
+	// BinfmtImageVersion is the version of the image providing static QEMU user mode emulators.
+	// renovate: datasource=docker versioning=loose depName=tonistiigi/binfmt
+	BinfmtImageVersion = "qemu-v9.2.2"
+
+	// BenchstatVersion is the version of benchstat.
+	// renovate: datasource=go depName=golang.org/x/perf
+	BenchstatVersion = "v0.0.0-20260409210113-8e83ce0f7b1c"
 	// CheckOutActionVersion is the version of checkout github action.
 	// renovate: datasource=github-tags depName=actions/checkout
 	CheckOutActionVersion = "v7.0.0"
@@ -22,12 +29,18 @@ const (
 	// renovate: datasource=github-tags depName=codecov/codecov-action
 	CodeCovActionVersion = "v7.0.0"
 	CodeCovActionRef     = "fb8b3582c8e4def4969c97caa2f19720cb33a72f"
+	// CodeCovCLIVersion is the version of codecov CLI used by GitLab CI jobs.
+	// renovate: datasource=github-releases depName=codecov/codecov-cli
+	CodeCovCLIVersion = "v10.4.0"
 	// DeepCopyVersion is the version of deepcopy.
 	// renovate: datasource=go depName=github.com/siderolabs/deep-copy
 	DeepCopyVersion = "v0.5.8"
 	// DindContainerImageVersion is the version of the dind container image.
 	// renovate: datasource=docker versioning=docker depName=docker
 	DindContainerImageVersion = "29.6-dind"
+	// DockerContainerImageVersion is the version of the docker CLI container image used by GitLab CI jobs.
+	// renovate: datasource=docker versioning=docker depName=docker
+	DockerContainerImageVersion = "29.6-cli"
 	// DockerfileFrontendImageVersion is the version of the dockerfile frontend image.
 	// renovate: datasource=docker versioning=docker depName=docker/dockerfile-upstream
 	DockerfileFrontendImageVersion = "1.25.0-labs"
@@ -39,6 +52,9 @@ const (
 	// renovate: datasource=github-tags depName=actions/github-script
 	GitHubScriptActionVersion = "v9.0.0"
 	GitHubScriptActionRef     = "3a2844b7e9c422d3c10d287c895573f7108da1b3"
+	// GitLabReleaseCLIImageVersion is the version of the GitLab release-cli container image.
+	// renovate: datasource=docker depName=registry.gitlab.com/gitlab-org/release-cli
+	GitLabReleaseCLIImageVersion = "v0.24.0"
 	// GoFmtVersion is the version of gofmt.
 	// renovate: datasource=go depName=github.com/mvdan/gofumpt
 	GoFmtVersion = "v0.10.0"
@@ -48,6 +64,9 @@ const (
 	// GoMockVersion is the version of gomock.
 	// renovate: datasource=go depName=github.com/uber-go/mock
 	GoMockVersion = "v0.6.0"
+	// GoTestSumVersion is the version of gotestsum.
+	// renovate: datasource=go depName=gotest.tools/gotestsum
+	GoTestSumVersion = "v1.12.0"
 	// GolangCIlintVersion is the version of golangci-lint.
 	// renovate: datasource=go depName=github.com/golangci/golangci-lint
 	GolangCIlintVersion = "v2.12.2"
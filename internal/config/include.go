@@ -0,0 +1,197 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"cmp"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// IncludeKind is the kind of the config document including other config files.
+const IncludeKind = "kres.Include"
+
+// profilePrefix is the path prefix of the config profiles embedded into kres.
+const profilePrefix = "profile:"
+
+// profiles are the versioned config profiles shipped with kres.
+//
+//go:embed profiles/*.yaml
+var profiles embed.FS
+
+// Include is the spec of the config document including other config files.
+//
+// The documents of the including file override the documents of the included files wherever the include document is:
+// documents with the same kind and name are deep-merged, mappings are merged key by key, while any other values are replaced.
+// The documents of the files included by the same file are merged in the order of the includes.
+//
+// Example:
+//
+//	---
+//	kind: kres.Include
+//	spec:
+//	  paths:
+//	    - ../.github/kres/base.yaml
+//	  profiles:
+//	    - go-release-binaries-v1
+type Include struct {
+	// Paths are config files to include, relative to the including file.
+	Paths []string `yaml:"paths"`
+
+	// Profiles are config profiles embedded into kres, see internal/config/profiles.
+	//
+	// Profiles are versioned by the name suffix, so that the changes of the profile don't affect
+	// the projects pinned to the previous version.
+	Profiles []string `yaml:"profiles"`
+}
+
+// ConfigKind implements the config kind override for the schema.
+func (*Include) ConfigKind() string {
+	return IncludeKind
+}
+
+// Profiles returns the names of the config profiles shipped with kres.
+func Profiles() []string {
+	entries, err := fs.ReadDir(profiles, "profiles")
+	if err != nil {
+		panic(err)
+	}
+
+	names := make([]string, 0, len(entries))
+
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".yaml"))
+	}
+
+	return names
+}
+
+func openConfig(path string) (io.ReadCloser, error) {
+	if name, ok := strings.CutPrefix(path, profilePrefix); ok {
+		r, err := profiles.Open("profiles/" + name + ".yaml")
+		if err != nil {
+			return nil, fmt.Errorf("unknown config profile %q, available profiles: %s", name, strings.Join(Profiles(), ", "))
+		}
+
+		return r, nil
+	}
+
+	return os.Open(path)
+}
+
+// include loads the files included by the document.
+func (provider *Provider) include(doc *Document, includedFrom []string) error {
+	var spec Include
+
+	if err := doc.Spec.Load(&spec, yaml.WithKnownFields()); err != nil {
+		return fmt.Errorf("%s: error decoding config block %v: %w", doc.location(), doc, err)
+	}
+
+	isProfile := strings.HasPrefix(doc.path, profilePrefix)
+
+	if isProfile && len(spec.Paths) > 0 {
+		return fmt.Errorf("%s: config profiles can only include other profiles", doc.location())
+	}
+
+	includes := make([]string, 0, len(spec.Paths)+len(spec.Profiles))
+
+	for _, path := range spec.Paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(doc.path), path)
+		}
+
+		includes = append(includes, path)
+	}
+
+	for _, profile := range spec.Profiles {
+		includes = append(includes, profilePrefix+profile)
+	}
+
+	for _, path := range includes {
+		if slices.Contains(includedFrom, path) {
+			return fmt.Errorf("%s: include cycle: %s -> %s", doc.location(), strings.Join(includedFrom, " -> "), path)
+		}
+
+		if err := provider.loadFile(path, includedFrom); err != nil {
+			return fmt.Errorf("%s: %w", doc.location(), err)
+		}
+	}
+
+	return nil
+}
+
+// mergeDocuments deep-merges the documents with the same kind and name.
+//
+// The documents of the included files are merged first, then the documents of the including files on top of them.
+// The merged document takes the place of the last one, as the later documents override the earlier ones.
+func (provider *Provider) mergeDocuments() {
+	docs := slices.Clone(provider.docs)
+
+	slices.SortStableFunc(docs, func(a, b Document) int {
+		return cmp.Compare(b.depth, a.depth)
+	})
+
+	merged := make([]Document, 0, len(docs))
+
+	for _, doc := range docs {
+		idx := slices.IndexFunc(merged, func(other Document) bool {
+			return other.Kind == doc.Kind && other.Name == doc.Name
+		})
+
+		if idx != -1 {
+			if !doc.Spec.IsZero() {
+				doc.Spec = *mergeNodes(&merged[idx].Spec, &doc.Spec)
+			} else {
+				doc.Spec = merged[idx].Spec
+			}
+
+			merged = slices.Delete(merged, idx, idx+1)
+		}
+
+		merged = append(merged, doc)
+	}
+
+	provider.docs = merged
+}
+
+// mergeNodes merges src node over dst node.
+func mergeNodes(dst, src *yaml.Node) *yaml.Node {
+	if dst.IsZero() || dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		return src
+	}
+
+	result := *dst
+	result.Content = slices.Clone(dst.Content)
+	result.Line, result.Column = src.Line, src.Column
+
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+
+		found := false
+
+		for j := 0; j+1 < len(result.Content); j += 2 {
+			if result.Content[j].Value == key.Value {
+				result.Content[j+1] = mergeNodes(result.Content[j+1], value)
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			result.Content = append(result.Content, key, value)
+		}
+	}
+
+	return &result
+}
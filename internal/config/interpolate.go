@@ -0,0 +1,241 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+// VarsKind is the kind of the config document defining variables.
+const VarsKind = "kres.Vars"
+
+// Vars is the spec of the config document defining variables for interpolation.
+//
+// Variables are referenced as ${vars.NAME}, the values might reference environment, metadata
+// and other variables. Later definitions of the same variable override the earlier ones,
+// the definitions of the including file override the included ones.
+//
+// Example:
+//
+//	---
+//	kind: kres.Vars
+//	spec:
+//	  registry: ghcr.io/${meta.GitHubOrganization}
+type Vars map[string]string
+
+// ConfigKind implements the config kind override for the schema.
+func (*Vars) ConfigKind() string {
+	return VarsKind
+}
+
+// referenceRe matches the references in config values:
+//
+//   - ${env:NAME} or ${env:NAME:-default} is replaced with the environment variable;
+//   - ${meta.Field} or ${meta.Field:-default} is replaced with the detected project metadata;
+//   - ${vars.name} or ${vars.name:-default} is replaced with the variable from kres.Vars documents.
+//
+// Default is used if the reference is unset or empty. $${...} escapes the reference.
+// Any other ${...} sequences, e.g. shell variables or GitHub Actions expressions, are kept as is.
+var referenceRe = regexp.MustCompile(`\$?\$\{(env:|meta\.|vars\.)([A-Za-z_][A-Za-z0-9_]*)(:-[^}]*)?\}`)
+
+// SetMetadata sets the detected project metadata available for ${meta.Field} references.
+//
+// Metadata should be a pointer to a struct, the exported fields of string, bool, number
+// or string slice type might be referenced.
+func (provider *Provider) SetMetadata(metadata any) {
+	provider.metadata = metadata
+}
+
+// interpolate replaces the references in the document spec.
+func (provider *Provider) interpolate(doc *Document) error {
+	if doc.interpolated {
+		return nil
+	}
+
+	doc.interpolated = true
+
+	var errs []error
+
+	walkScalars(&doc.Spec, func(node *yaml.Node) {
+		value, err := provider.expand(node.Value, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s:%d: %w", doc.path, node.Line, err))
+
+			return
+		}
+
+		if value == node.Value {
+			return
+		}
+
+		node.Value = value
+
+		// re-resolve the type of the plain scalar, so that e.g. ${env:DEBUG:-false} is decoded into bool
+		if node.Style == 0 {
+			node.Tag = ""
+		}
+	})
+
+	return errors.Join(errs...)
+}
+
+// expand replaces the references in the value.
+func (provider *Provider) expand(value string, expanding []string) (string, error) {
+	var errs []error
+
+	result := referenceRe.ReplaceAllStringFunc(value, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+
+		groups := referenceRe.FindStringSubmatch(match)
+		source, name, fallback := groups[1], groups[2], groups[3]
+
+		resolved, ok, err := provider.resolve(source, name, expanding)
+		if err != nil {
+			errs = append(errs, err)
+
+			return match
+		}
+
+		if !ok || resolved == "" {
+			if fallback == "" {
+				if !ok {
+					errs = append(errs, fmt.Errorf("unresolved reference %s", match))
+				}
+
+				return ""
+			}
+
+			return strings.TrimPrefix(fallback, ":-")
+		}
+
+		return resolved
+	})
+
+	return result, errors.Join(errs...)
+}
+
+// resolve returns the value of the reference.
+func (provider *Provider) resolve(source, name string, expanding []string) (string, bool, error) {
+	switch source {
+	case "env:":
+		value, ok := os.LookupEnv(name)
+
+		return value, ok, nil
+	case "meta.":
+		return provider.resolveMetadata(name)
+	default:
+		value, ok := provider.vars[name]
+		if !ok {
+			return "", false, nil
+		}
+
+		if slices.Contains(expanding, name) {
+			return "", false, fmt.Errorf("variable reference cycle: %s -> %s", strings.Join(expanding, " -> "), name)
+		}
+
+		value, err := provider.expand(value, append(expanding, name))
+
+		return value, true, err
+	}
+}
+
+// resolveMetadata returns the metadata field as a string, the value is recorded to be checked with CheckMetadata.
+func (provider *Provider) resolveMetadata(name string) (string, bool, error) {
+	value, ok, err := provider.metadataValue(name)
+	if err != nil || !ok {
+		return value, ok, err
+	}
+
+	if provider.resolvedMetadata == nil {
+		provider.resolvedMetadata = map[string]string{}
+	}
+
+	if _, recorded := provider.resolvedMetadata[name]; !recorded {
+		provider.resolvedMetadata[name] = value
+	}
+
+	return value, true, nil
+}
+
+// CheckMetadata returns an error for every referenced metadata field which changed after it was interpolated.
+//
+// The metadata is completed while the config is loaded (e.g. the platforms are set by the toolchain),
+// so the references to the fields which are not final yet would silently resolve to the stale values.
+func (provider *Provider) CheckMetadata() error {
+	var errs []error
+
+	for _, name := range slices.Sorted(maps.Keys(provider.resolvedMetadata)) {
+		resolved := provider.resolvedMetadata[name]
+
+		value, _, err := provider.metadataValue(name)
+		if err != nil {
+			return err
+		}
+
+		if value != resolved {
+			errs = append(errs, fmt.Errorf("${meta.%s} was resolved to %q before the metadata was final (%q), it can't be referenced", name, resolved, value))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// metadataValue returns the metadata field as a string.
+func (provider *Provider) metadataValue(name string) (string, bool, error) {
+	if provider.metadata == nil {
+		return "", false, nil
+	}
+
+	val := reflect.Indirect(reflect.ValueOf(provider.metadata))
+
+	field := val.FieldByName(name)
+	if !field.IsValid() || !field.CanInterface() {
+		return "", false, fmt.Errorf("unknown metadata field %q", name)
+	}
+
+	switch field.Kind() { //nolint:exhaustive
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return fmt.Sprint(field.Interface()), true, nil
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.String {
+			values := make([]string, 0, field.Len())
+
+			for i := range field.Len() {
+				values = append(values, field.Index(i).String())
+			}
+
+			return strings.Join(values, ","), true, nil
+		}
+	}
+
+	return "", false, fmt.Errorf("metadata field %q of type %s can't be referenced", name, field.Type())
+}
+
+// walkScalars calls fn for each scalar value in the tree, mapping keys are skipped.
+func walkScalars(node *yaml.Node, fn func(*yaml.Node)) {
+	switch node.Kind { //nolint:exhaustive
+	case yaml.ScalarNode:
+		fn(node)
+	case yaml.MappingNode:
+		for i := 1; i < len(node.Content); i += 2 {
+			walkScalars(node.Content[i], fn)
+		}
+	default:
+		for _, child := range node.Content {
+			walkScalars(child, fn)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"maps"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// SchemaURI is the JSON Schema dialect of the generated schema.
+const SchemaURI = "https://json-schema.org/draft/2020-12/schema"
+
+// Kind returns the config kind of the object type, e.g. `golang.Toolchain`.
+func Kind(typ reflect.Type) string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	return path.Base(typ.PkgPath()) + "." + typ.Name()
+}
+
+// Schema builds a JSON Schema of a single config document for the passed objects.
+//
+// Each object is a pointer to the type config documents are loaded into,
+// spec schema is derived from the yaml tags of the type.
+// The kind might be overridden by the object with ConfigKind method.
+func Schema(objs ...any) map[string]any {
+	kinds := make([]string, 0, len(objs))
+	conditions := make([]any, 0, len(objs))
+
+	for _, obj := range objs {
+		typ := reflect.TypeOf(obj)
+		kind := Kind(typ)
+
+		if kinded, ok := obj.(interface{ ConfigKind() string }); ok {
+			kind = kinded.ConfigKind()
+		}
+
+		kinds = append(kinds, kind)
+
+		then := map[string]any{
+			"properties": map[string]any{
+				"spec": typeSchema(typ, nil),
+			},
+		}
+
+		if _, ok := obj.(interface{ Name() string }); !ok {
+			then["properties"].(map[string]any)["name"] = false //nolint:forcetypeassert
+		}
+
+		conditions = append(conditions, map[string]any{
+			"if": map[string]any{
+				"properties": map[string]any{
+					"kind": map[string]any{"const": kind},
+				},
+			},
+			"then": then,
+		})
+	}
+
+	slices.Sort(kinds)
+
+	return map[string]any{
+		"$schema":     SchemaURI,
+		"title":       "Kres configuration document",
+		"description": "Each document of .kres.yaml overrides the configuration of the project node matching kind and name.",
+		"type":        "object",
+		"properties": map[string]any{
+			"kind": map[string]any{
+				"description": "Package and type name of the configured object, e.g. `golang.Toolchain`.",
+				"enum":        kinds,
+			},
+			"name": map[string]any{
+				"description": "Name of the configured object (if supported).",
+				"type":        "string",
+			},
+			"spec": map[string]any{
+				"description": "Configuration loaded into the matching object.",
+			},
+		},
+		"required":             []string{"kind", "spec"},
+		"additionalProperties": false,
+		"allOf":                conditions,
+	}
+}
+
+func typeSchema(typ reflect.Type, visiting []reflect.Type) map[string]any {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	// custom decoding and recursive types accept anything
+	if hasCustomUnmarshaler(typ) || slices.Contains(visiting, typ) {
+		return map[string]any{}
+	}
+
+	switch typ.Kind() { //nolint:exhaustive
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{
+			"type":  "array",
+			"items": typeSchema(typ.Elem(), visiting),
+		}
+	case reflect.Map:
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": typeSchema(typ.Elem(), visiting),
+		}
+	case reflect.Struct:
+		return structSchema(typ, append(visiting, typ))
+	default:
+		return map[string]any{}
+	}
+}
+
+func structSchema(typ reflect.Type, visiting []reflect.Type) map[string]any {
+	properties := map[string]any{}
+
+	var additionalProperties any = false
+
+	for field := range typ.Fields() {
+		tag, flags, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		// embedded types without a tag are composition, e.g. dag.BaseNode
+		if field.Anonymous && tag == "" && flags == "" {
+			continue
+		}
+
+		if slices.Contains(strings.Split(flags, ","), "inline") {
+			inlined := typeSchema(field.Type, visiting)
+
+			if inlineProperties, ok := inlined["properties"].(map[string]any); ok {
+				maps.Copy(properties, inlineProperties)
+			}
+
+			if inlineAdditional, ok := inlined["additionalProperties"]; ok && inlineAdditional != false {
+				additionalProperties = inlineAdditional
+			}
+
+			continue
+		}
+
+		if tag == "" {
+			tag = strings.ToLower(field.Name)
+		}
+
+		properties[tag] = typeSchema(field.Type, visiting)
+	}
+
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": additionalProperties,
+	}
+}
+
+func hasCustomUnmarshaler(typ reflect.Type) bool {
+	_, ok := reflect.PointerTo(typ).MethodByName("UnmarshalYAML")
+
+	return ok
+}
@@ -0,0 +1,95 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package config_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/internal/config"
+)
+
+type Embedded struct {
+	hidden string //nolint:unused
+}
+
+type Custom struct{}
+
+func (*Custom) UnmarshalYAML(func(any) error) error {
+	return nil
+}
+
+type Inlined struct {
+	Values map[string]string `yaml:",inline"`
+	Labels []string          `yaml:"labels,omitempty"`
+}
+
+type Schemed struct { //nolint:govet
+	Embedded
+
+	Untagged string
+	Count    *int              `yaml:"count"`
+	Ratio    float64           `yaml:"ratio"`
+	Enabled  bool              `yaml:"enabled"`
+	Inlined  Inlined           `yaml:"inlined"`
+	Custom   Custom            `yaml:"custom"`
+	Env      map[string]string `yaml:"env"`
+	Any      any               `yaml:"any"`
+	Ignored  string            `yaml:"-"`
+
+	private string //nolint:unused
+}
+
+func TestSchema(t *testing.T) {
+	schema := config.Schema(&Schemed{}, &Foo{})
+
+	raw, err := json.Marshal(schema)
+	require.NoError(t, err)
+
+	var decoded struct {
+		Properties struct {
+			Kind struct {
+				Enum []string `json:"enum"`
+			} `json:"kind"`
+		} `json:"properties"`
+		AllOf []struct {
+			Then struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"then"`
+		} `json:"allOf"`
+	}
+
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+
+	assert.Equal(t, []string{"config_test.Foo", "config_test.Schemed"}, decoded.Properties.Kind.Enum)
+	require.Len(t, decoded.AllOf, 2)
+
+	assert.JSONEq(t, `{
+		"type": "object",
+		"additionalProperties": false,
+		"properties": {
+			"untagged": {"type": "string"},
+			"count": {"type": "integer"},
+			"ratio": {"type": "number"},
+			"enabled": {"type": "boolean"},
+			"inlined": {
+				"type": "object",
+				"additionalProperties": {"type": "string"},
+				"properties": {
+					"labels": {"type": "array", "items": {"type": "string"}}
+				}
+			},
+			"custom": {},
+			"env": {"type": "object", "additionalProperties": {"type": "string"}},
+			"any": {}
+		}
+	}`, string(decoded.AllOf[0].Then.Properties["spec"]))
+	assert.JSONEq(t, `false`, string(decoded.AllOf[0].Then.Properties["name"]))
+
+	assert.NotContains(t, decoded.AllOf[1].Then.Properties, "name")
+}
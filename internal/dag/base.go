@@ -53,6 +53,33 @@ func (node *BaseNode) AddInput(input ...Node) {
 	node.inputs = append(node.inputs, input...)
 }
 
+// ReplaceInput implements Node interface.
+//
+// The input is replaced in place, replacements which are already inputs of the node are skipped.
+func (node *BaseNode) ReplaceInput(input Node, replacements ...Node) {
+	idx := slices.Index(node.inputs, input)
+	if idx == -1 {
+		return
+	}
+
+	replacements = slices.DeleteFunc(slices.Clone(replacements), func(replacement Node) bool {
+		return slices.Contains(node.inputs, replacement)
+	})
+
+	node.inputs = slices.Replace(node.inputs, idx, idx+1, replacements...)
+
+	input.RemoveParent(node)
+
+	for _, replacement := range replacements {
+		replacement.AddParent(node)
+	}
+}
+
+// RemoveParent implements Node interface.
+func (node *BaseNode) RemoveParent(parent Node) {
+	node.parents = slices.DeleteFunc(node.parents, func(n Node) bool { return n == parent })
+}
+
 // BaseGraph implements core functionality of DAG.
 //
 // BaseGraph is designed to be embedded into other types.
@@ -69,3 +96,59 @@ func (graph *BaseGraph) Targets() []Node {
 func (graph *BaseGraph) AddTarget(target ...Node) {
 	graph.targets = append(graph.targets, target...)
 }
+
+// Drop removes the node from the graph.
+//
+// Dependants of the node (and the graph, if the node is a target) get the inputs of the node instead.
+func (graph *BaseGraph) Drop(node Node) {
+	inputs := slices.Clone(node.Inputs())
+
+	for _, input := range inputs {
+		node.ReplaceInput(input)
+	}
+
+	for _, parent := range slices.Clone(node.Parents()) {
+		parent.ReplaceInput(node, inputs...)
+	}
+
+	graph.replaceTarget(node, inputs...)
+}
+
+// Replace replaces the node in the graph with the replacement.
+//
+// The replacement takes over the inputs and the dependants of the node.
+func (graph *BaseGraph) Replace(node, replacement Node) {
+	inputs := slices.Clone(node.Inputs())
+
+	for _, input := range inputs {
+		node.ReplaceInput(input)
+	}
+
+	// the replacement might depend on the node itself
+	replacement.ReplaceInput(node, inputs...)
+
+	for _, input := range inputs {
+		if !slices.Contains(replacement.Inputs(), input) {
+			replacement.AddInput(input)
+		}
+	}
+
+	for _, parent := range slices.Clone(node.Parents()) {
+		parent.ReplaceInput(node, replacement)
+	}
+
+	graph.replaceTarget(node, replacement)
+}
+
+func (graph *BaseGraph) replaceTarget(target Node, replacements ...Node) {
+	idx := slices.Index(graph.targets, target)
+	if idx == -1 {
+		return
+	}
+
+	replacements = slices.DeleteFunc(slices.Clone(replacements), func(replacement Node) bool {
+		return slices.Contains(graph.targets, replacement)
+	})
+
+	graph.targets = slices.Replace(graph.targets, idx, idx+1, replacements...)
+}
@@ -0,0 +1,77 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package dag_test
+
+import (
+	"testing"
+
+	"github.com/siderolabs/gen/xslices"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/siderolabs/kres/internal/dag"
+)
+
+type node struct {
+	dag.BaseNode
+}
+
+func newNode(name string) *node {
+	return &node{BaseNode: dag.NewBaseNode(name)}
+}
+
+func names(nodes []dag.Node) []string {
+	return xslices.Map(nodes, dag.Node.Name)
+}
+
+// buildGraph builds graph: all -> (lint, build), lint -> (fmt, vet), fmt -> base, vet -> base.
+func buildGraph() (*dag.BaseGraph, map[string]*node) {
+	nodes := map[string]*node{}
+
+	for _, name := range []string{"all", "lint", "build", "fmt", "vet", "base"} {
+		nodes[name] = newNode(name)
+	}
+
+	nodes["all"].AddInput(nodes["lint"], nodes["build"])
+	nodes["lint"].AddInput(nodes["fmt"], nodes["vet"])
+	nodes["fmt"].AddInput(nodes["base"])
+	nodes["vet"].AddInput(nodes["base"])
+
+	graph := &dag.BaseGraph{}
+	graph.AddTarget(nodes["all"], nodes["lint"])
+
+	return graph, nodes
+}
+
+func TestDrop(t *testing.T) {
+	graph, nodes := buildGraph()
+
+	graph.Drop(nodes["fmt"])
+
+	assert.Equal(t, []string{"base", "vet"}, names(nodes["lint"].Inputs()))
+	assert.Empty(t, nodes["fmt"].Inputs())
+	assert.Len(t, nodes["base"].Parents(), 2)
+
+	graph.Drop(nodes["lint"])
+
+	assert.Equal(t, []string{"base", "vet", "build"}, names(nodes["all"].Inputs()))
+	assert.Equal(t, []string{"all", "base", "vet"}, names(graph.Targets()))
+	assert.Nil(t, dag.FindByName("lint", graph.Targets()...))
+	assert.Nil(t, dag.FindByName("fmt", graph.Targets()...))
+}
+
+func TestReplace(t *testing.T) {
+	graph, nodes := buildGraph()
+
+	custom := newNode("lint")
+	custom.AddInput(nodes["lint"], nodes["build"])
+
+	graph.Replace(nodes["lint"], custom)
+
+	assert.Equal(t, []string{"fmt", "vet", "build"}, names(custom.Inputs()))
+	assert.Equal(t, []dag.Node{custom, nodes["build"]}, nodes["all"].Inputs())
+	assert.Equal(t, []dag.Node{nodes["all"], custom}, graph.Targets())
+	assert.Empty(t, nodes["lint"].Inputs())
+	assert.Len(t, nodes["fmt"].Parents(), 1)
+}
@@ -17,6 +17,8 @@ type Node interface {
 	Parents() []Node
 	AddInput(...Node)
 	AddParent(Node)
+	ReplaceInput(Node, ...Node)
+	RemoveParent(Node)
 }
 
 // NodeCondition checks the node for a specific condition.
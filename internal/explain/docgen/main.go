@@ -0,0 +1,71 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package main generates the doc comments table of the config types for `kres explain`.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/siderolabs/kres/internal/explain"
+	"github.com/siderolabs/kres/internal/output"
+)
+
+func main() {
+	root := flag.String("root", ".", "module root directory")
+	dir := flag.String("dir", "internal", "directory to extract the docs from, relative to the module root")
+	out := flag.String("out", "docs_generated.go", "output file")
+
+	flag.Parse()
+
+	if err := run(*root, *dir, *out); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(root, dir, out string) error {
+	goMod, err := os.ReadFile(root + "/go.mod")
+	if err != nil {
+		return err
+	}
+
+	module := modfile.ModulePath(goMod)
+	if module == "" {
+		return fmt.Errorf("module path not found in %s/go.mod", root)
+	}
+
+	docs, err := explain.ExtractDocs(root, module, dir)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "%s\n", output.License(output.MPLHeader, "// "))
+	fmt.Fprintf(&buf, "// Code generated by docgen. DO NOT EDIT.\n\npackage explain\n\n")
+	fmt.Fprintf(&buf, "var docs = map[string]string{\n")
+
+	for _, key := range slices.Sorted(maps.Keys(docs)) {
+		fmt.Fprintf(&buf, "\t%s: %s,\n", strconv.Quote(key), strconv.Quote(docs[key]))
+	}
+
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(out, src, 0o644)
+}
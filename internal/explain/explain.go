@@ -0,0 +1,305 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package explain documents the configuration kinds of `.kres.yaml`.
+package explain
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+)
+
+//go:generate go run ./docgen -root ../.. -dir internal -out docs_generated.go
+
+// Field describes a config field of the kind.
+type Field struct {
+	// Name is the yaml name of the field.
+	Name string
+	// Path is the dot-separated yaml path of the field in the spec.
+	Path string
+	// Type is the Go type of the field.
+	Type string
+	// Doc is the doc comment of the field.
+	Doc string
+	// Zero is the zero value of the field formatted as YAML.
+	Zero string
+	// Fields are the nested fields of the struct-typed fields.
+	Fields []Field
+}
+
+// Doc returns the doc comment of the type.
+func Doc(typ reflect.Type) string {
+	typ = indirect(typ)
+
+	return docs[typ.PkgPath()+"."+typ.Name()]
+}
+
+// Fields returns the tree of the config fields of the type, as they are loaded from `.kres.yaml`.
+func Fields(typ reflect.Type) []Field {
+	typ = indirect(typ)
+
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return structFields(typ, typ.PkgPath()+"."+typ.Name(), "", []reflect.Type{typ})
+}
+
+func structFields(typ reflect.Type, docKey, prefix string, visiting []reflect.Type) []Field {
+	var fields []Field
+
+	for field := range typ.Fields() {
+		name, inline, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+
+		fieldType := indirect(field.Type)
+
+		if inline {
+			fields = append(fields, structFields(fieldType, typeDocKey(fieldType, docKey+"."+field.Name), prefix, visiting)...)
+
+			continue
+		}
+
+		f := Field{
+			Name: name,
+			Path: prefix + name,
+			Type: typeName(field.Type),
+			Doc:  docs[docKey+"."+field.Name],
+		}
+
+		if nestedStruct(fieldType) != fieldType {
+			f.Zero = formatValue(reflect.Zero(field.Type).Interface())
+		}
+
+		if nested := nestedStruct(fieldType); nested != nil && !slices.Contains(visiting, nested) {
+			f.Fields = structFields(nested, typeDocKey(nested, docKey+"."+field.Name), f.Path+".", append(visiting, nested))
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+// Values returns the values of the config fields of the object keyed by the field path.
+//
+// Fields of the nested structs are flattened, any other values are formatted as YAML.
+// Zero values are skipped.
+func Values(obj any) map[string]string {
+	values := map[string]string{}
+
+	if val := reflect.Indirect(reflect.ValueOf(obj)); val.Kind() == reflect.Struct {
+		structValues(values, val, "")
+	}
+
+	return values
+}
+
+func structValues(values map[string]string, val reflect.Value, prefix string) {
+	for i, field := range slices.Collect(val.Type().Fields()) {
+		name, inline, ok := fieldName(field)
+		if !ok {
+			continue
+		}
+
+		fieldVal := reflect.Indirect(val.Field(i))
+		if !fieldVal.IsValid() {
+			continue
+		}
+
+		switch {
+		case inline:
+			structValues(values, fieldVal, prefix)
+		case fieldVal.Kind() == reflect.Struct && !hasCustomUnmarshaler(fieldVal.Type()):
+			structValues(values, fieldVal, prefix+name+".")
+		case !fieldVal.IsZero():
+			values[prefix+name] = formatValue(fieldVal.Interface())
+		}
+	}
+}
+
+// formatValue formats the value as YAML, collections are formatted in the flow style.
+func formatValue(value any) string {
+	var node yaml.Node
+
+	if err := node.Dump(value); err != nil {
+		return fmt.Sprint(value)
+	}
+
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style = yaml.FlowStyle
+	}
+
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// Write prints the documentation of the kind.
+//
+// Defaults are the values set by the node constructor, values are the effective values
+// after loading `.kres.yaml`, both are optional.
+func Write(w io.Writer, kind string, obj any, names []string, defaults, values map[string]string) error {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "KIND: %s\n", kind)
+
+	if len(names) > 0 {
+		fmt.Fprintf(&sb, "NAMES: %s\n", strings.Join(names, ", "))
+	}
+
+	if doc := Doc(reflect.TypeOf(obj)); doc != "" {
+		fmt.Fprintf(&sb, "\nDESCRIPTION:\n%s\n", indent(doc, "  "))
+	}
+
+	fields := Fields(reflect.TypeOf(obj))
+
+	if len(fields) > 0 {
+		fmt.Fprintf(&sb, "\nFIELDS:\n")
+
+		writeFields(&sb, fields, "  ", defaults, values)
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+func writeFields(sb *strings.Builder, fields []Field, prefix string, defaults, values map[string]string) {
+	for _, field := range fields {
+		fmt.Fprintf(sb, "%s%s <%s>\n", prefix, field.Name, field.Type)
+
+		if field.Doc != "" {
+			fmt.Fprintf(sb, "%s\n", indent(field.Doc, prefix+"    "))
+		}
+
+		if value, ok := defaults[field.Path]; ok {
+			writeValue(sb, "default", value, prefix+"    ")
+		}
+
+		if value, ok := values[field.Path]; ok {
+			writeValue(sb, "value", value, prefix+"    ")
+		} else if _, overridden := defaults[field.Path]; overridden && values != nil {
+			// the default is reset to the zero value by the config
+			writeValue(sb, "value", field.Zero, prefix+"    ")
+		}
+
+		writeFields(sb, field.Fields, prefix+"  ", defaults, values)
+	}
+}
+
+func writeValue(sb *strings.Builder, label, value, prefix string) {
+	if !strings.Contains(value, "\n") {
+		fmt.Fprintf(sb, "%s%s: %s\n", prefix, label, value)
+
+		return
+	}
+
+	fmt.Fprintf(sb, "%s%s:\n%s\n", prefix, label, indent(value, prefix+"  "))
+}
+
+// fieldName returns the yaml name of the field, and whether it is inlined.
+func fieldName(field reflect.StructField) (string, bool, bool) {
+	tag, flags, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+
+	if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+		return "", false, false
+	}
+
+	// embedded types without a tag are composition, e.g. dag.BaseNode
+	if field.Anonymous && tag == "" && flags == "" {
+		return "", false, false
+	}
+
+	if slices.Contains(strings.Split(flags, ","), "inline") {
+		return "", true, true
+	}
+
+	if tag == "" {
+		tag = strings.ToLower(field.Name)
+	}
+
+	return tag, false, true
+}
+
+// typeName returns the name of the type, anonymous structs are shown as objects.
+func typeName(typ reflect.Type) string {
+	switch typ.Kind() { //nolint:exhaustive
+	case reflect.Pointer:
+		return typeName(typ.Elem())
+	case reflect.Slice, reflect.Array:
+		return "[]" + typeName(typ.Elem())
+	case reflect.Map:
+		return "map[" + typeName(typ.Key()) + "]" + typeName(typ.Elem())
+	case reflect.Struct:
+		if typ.Name() == "" {
+			return "object"
+		}
+	}
+
+	return typ.String()
+}
+
+// nestedStruct returns the struct type of the field value or its elements.
+func nestedStruct(typ reflect.Type) reflect.Type {
+	for {
+		switch typ.Kind() { //nolint:exhaustive
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			typ = typ.Elem()
+		case reflect.Struct:
+			if hasCustomUnmarshaler(typ) {
+				return nil
+			}
+
+			return typ
+		default:
+			return nil
+		}
+	}
+}
+
+// typeDocKey returns the doc key of the named type, anonymous structs are documented under the field key.
+func typeDocKey(typ reflect.Type, fieldKey string) string {
+	if typ.Name() == "" {
+		return fieldKey
+	}
+
+	return typ.PkgPath() + "." + typ.Name()
+}
+
+func indirect(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	return typ
+}
+
+func hasCustomUnmarshaler(typ reflect.Type) bool {
+	_, ok := reflect.PointerTo(typ).MethodByName("UnmarshalYAML")
+
+	return ok
+}
+
+func indent(text, prefix string) string {
+	lines := strings.Split(text, "\n")
+
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
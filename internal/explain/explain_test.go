@@ -0,0 +1,81 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package explain_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/internal/explain"
+	"github.com/siderolabs/kres/internal/project/golang"
+)
+
+func TestDocsUpToDate(t *testing.T) {
+	docs, err := explain.ExtractDocs("../..", "github.com/siderolabs/kres", "internal")
+	require.NoError(t, err)
+
+	assert.Equal(t, docs, explain.Docs, "doc comments are out of date, run `go generate ./internal/explain`")
+}
+
+func TestFields(t *testing.T) {
+	fields := explain.Fields(reflect.TypeFor[golang.Toolchain]())
+
+	names := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		names = append(names, field.Name)
+	}
+
+	assert.Equal(t, []string{"kind", "version", "image", "extraPackages", "privateRepos", "makefile", "docker", "defaultBuildTags", "buildTags", "platforms"}, names)
+
+	makefile := fields[5]
+	assert.Equal(t, "object", makefile.Type)
+	require.Len(t, makefile.Fields, 1)
+	assert.Equal(t, explain.Field{
+		Name: "extraVariables",
+		Path: "makefile.extraVariables",
+		Type: "[]object",
+		Zero: "[]",
+		Fields: []explain.Field{
+			{Name: "name", Path: "makefile.extraVariables.name", Type: "string", Zero: "''"},
+			{Name: "defaultValue", Path: "makefile.extraVariables.defaultValue", Type: "string", Zero: "''"},
+		},
+	}, makefile.Fields[0])
+
+	assert.Equal(t, "DefaultBuildTags is a list of build tags to be always enabled.", fields[7].Doc)
+	assert.Equal(t, "Toolchain provides Go compiler and common utilities.", explain.Doc(reflect.TypeFor[*golang.Toolchain]()))
+}
+
+func TestValues(t *testing.T) {
+	toolchain := &golang.Toolchain{
+		Version:          "1.26",
+		DefaultBuildTags: []string{"sidero.debug", "tools"},
+	}
+	toolchain.Docker.ExtraArgs = []string{"--pull"}
+
+	assert.Equal(t, map[string]string{
+		"version":          "'1.26'",
+		"defaultBuildTags": "[sidero.debug, tools]",
+		"docker.extraArgs": "[--pull]",
+	}, explain.Values(toolchain))
+}
+
+func TestWrite(t *testing.T) {
+	var out strings.Builder
+
+	require.NoError(t, explain.Write(&out, "golang.Toolchain", &golang.Toolchain{}, []string{"base"},
+		map[string]string{"version": "'1.26'", "buildTags": "[debug]"},
+		map[string]string{"version": "'1.25'", "docker.extraArgs": "[--pull]"},
+	))
+
+	assert.Contains(t, out.String(), "KIND: golang.Toolchain\nNAMES: base\n\nDESCRIPTION:\n  Toolchain provides Go compiler and common utilities.\n\nFIELDS:\n")
+	assert.Contains(t, out.String(), "  version <string>\n      default: '1.26'\n      value: '1.25'\n")
+	assert.Contains(t, out.String(), "      default: [debug]\n      value: []\n")
+	assert.Contains(t, out.String(), "  docker <object>\n    extraArgs <[]string>\n        value: [--pull]\n")
+}
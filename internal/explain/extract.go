@@ -0,0 +1,157 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package explain
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ExtractDocs parses Go sources of the module under the dir and returns doc comments
+// of the exported struct types and their fields.
+//
+// The keys are `<import path>.<Type>` for types and `<import path>.<Type>.<Field>` for fields,
+// fields of the anonymous structs are keyed by the full field path, e.g. `<import path>.Toolchain.Docker.ExtraArgs`.
+func ExtractDocs(root, module, dir string) (map[string]string, error) {
+	docs := map[string]string{}
+
+	err := filepath.WalkDir(filepath.Join(root, dir), func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if d.Name() == "testdata" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if filepath.Ext(p) != ".go" || strings.HasSuffix(p, "_test.go") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, filepath.Dir(p))
+		if err != nil {
+			return err
+		}
+
+		file, err := parser.ParseFile(token.NewFileSet(), p, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+
+		extractFileDocs(docs, path.Join(module, filepath.ToSlash(rel)), file)
+
+		return nil
+	})
+
+	return docs, err
+}
+
+func extractFileDocs(docs map[string]string, pkgPath string, file *ast.File) {
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec) //nolint:forcetypeassert,errcheck
+
+			if !typeSpec.Name.IsExported() {
+				continue
+			}
+
+			key := pkgPath + "." + typeSpec.Name.Name
+
+			doc := typeSpec.Doc
+			if doc == nil && len(genDecl.Specs) == 1 {
+				doc = genDecl.Doc
+			}
+
+			addDoc(docs, key, doc)
+
+			if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+				extractFieldDocs(docs, key, structType)
+			}
+		}
+	}
+}
+
+func extractFieldDocs(docs map[string]string, prefix string, structType *ast.StructType) {
+	for _, field := range structType.Fields.List {
+		doc := field.Doc
+		if doc == nil {
+			doc = field.Comment
+		}
+
+		names := make([]string, 0, len(field.Names))
+
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+
+		if len(names) == 0 {
+			// embedded field is named after the type
+			names = append(names, embeddedName(field.Type))
+		}
+
+		for _, name := range names {
+			if !ast.IsExported(name) {
+				continue
+			}
+
+			addDoc(docs, prefix+"."+name, doc)
+
+			if nested := anonymousStruct(field.Type); nested != nil {
+				extractFieldDocs(docs, prefix+"."+name, nested)
+			}
+		}
+	}
+}
+
+// anonymousStruct returns the anonymous struct type of the field, possibly wrapped into pointers, slices or maps.
+func anonymousStruct(expr ast.Expr) *ast.StructType {
+	for {
+		switch typ := expr.(type) {
+		case *ast.StructType:
+			return typ
+		case *ast.StarExpr:
+			expr = typ.X
+		case *ast.ArrayType:
+			expr = typ.Elt
+		case *ast.MapType:
+			expr = typ.Value
+		default:
+			return nil
+		}
+	}
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch typ := expr.(type) {
+	case *ast.StarExpr:
+		return embeddedName(typ.X)
+	case *ast.SelectorExpr:
+		return typ.Sel.Name
+	case *ast.Ident:
+		return typ.Name
+	default:
+		return ""
+	}
+}
+
+func addDoc(docs map[string]string, key string, doc *ast.CommentGroup) {
+	if text := strings.TrimSpace(doc.Text()); text != "" {
+		docs[key] = text
+	}
+}
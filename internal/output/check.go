@@ -0,0 +1,139 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package output
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Checker is an FS which records the changes outputs would make to the underlying FS
+// without applying them.
+//
+// Every file whose contents differ from the generated ones (ignoring the preamble) and every
+// file which would be removed is reported as a unified diff.
+type Checker struct {
+	FS
+
+	w       io.Writer
+	drifted []string
+}
+
+// NewChecker creates a Checker on top of base which prints a unified diff for every drifted file to w.
+func NewChecker(base FS, w io.Writer) *Checker {
+	return &Checker{
+		FS: base,
+		w:  w,
+	}
+}
+
+// Drifted returns the sorted list of files which are out of date.
+func (checker *Checker) Drifted() []string {
+	return slices.Sorted(slices.Values(checker.drifted))
+}
+
+// MkdirAll implements FS.
+func (checker *Checker) MkdirAll(string, fs.FileMode) error {
+	return nil
+}
+
+// Chmod implements FS.
+func (checker *Checker) Chmod(string, fs.FileMode) error {
+	return nil
+}
+
+// WriteFile implements FS.
+func (checker *Checker) WriteFile(name string, data []byte, _ fs.FileMode) error {
+	name = cleanPath(name)
+
+	oldContents, exists, err := checker.read(name)
+	if err != nil {
+		return err
+	}
+
+	newContents, err := splitIgnoringPreamble(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	if exists && strings.Join(oldContents, "\n") == strings.Join(newContents, "\n") {
+		return nil
+	}
+
+	fromFile := "a/" + name
+	if !exists {
+		fromFile = os.DevNull
+	}
+
+	return checker.report(name, fromFile, "b/"+name, oldContents, newContents)
+}
+
+// Remove implements FS.
+func (checker *Checker) Remove(name string) error {
+	name = cleanPath(name)
+
+	oldContents, exists, err := checker.read(name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return checker.report(name, "a/"+name, os.DevNull, oldContents, nil)
+}
+
+func (checker *Checker) read(name string) ([]string, bool, error) {
+	contents, err := fs.ReadFile(checker.FS, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, false, nil
+		}
+
+		return nil, false, err
+	}
+
+	lines, err := splitIgnoringPreamble(bytes.NewReader(contents))
+
+	return lines, true, err
+}
+
+func (checker *Checker) report(filename, fromFile, toFile string, oldContents, newContents []string) error {
+	checker.drifted = append(checker.drifted, filename)
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        joinLines(oldContents),
+		B:        joinLines(newContents),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprint(checker.w, diff)
+
+	return err
+}
+
+func joinLines(lines []string) []string {
+	result := make([]string, 0, len(lines))
+
+	for _, line := range lines {
+		result = append(result, line+"\n")
+	}
+
+	return result
+}
@@ -8,7 +8,11 @@ package dockerfile
 import (
 	"fmt"
 	"io"
+	"slices"
 	"sort"
+	"strings"
+
+	"github.com/siderolabs/gen/xslices"
 
 	"github.com/siderolabs/kres/internal/config"
 	"github.com/siderolabs/kres/internal/output"
@@ -26,6 +30,7 @@ type Output struct {
 	output.FileAdapter
 
 	stages  map[string]*Stage
+	images  map[string]struct{}
 	args    []*step.ArgStep
 	enabled bool
 }
@@ -39,6 +44,17 @@ func NewOutput() *Output {
 	return output
 }
 
+// AllowImage allows the bare image name (e.g. `alpine`) to be referenced via FROM or COPY --from.
+//
+// Other bare names must refer to the defined stages.
+func (o *Output) AllowImage(name string) {
+	if o.images == nil {
+		o.images = map[string]struct{}{}
+	}
+
+	o.images[name] = struct{}{}
+}
+
 // Compile implements [output.TypedWriter] interface.
 func (o *Output) Compile(compiler Compiler) error {
 	return compiler.CompileDockerfile(o)
@@ -89,6 +105,24 @@ func (o *Output) Arg(arg *step.ArgStep) *Output {
 }
 
 func (o *Output) dockerfile(w io.Writer) error {
+	stageNodes := make([]*Stage, 0, len(o.stages))
+	for _, stage := range o.stages {
+		stageNodes = append(stageNodes, stage)
+	}
+
+	sort.Slice(stageNodes, func(i, j int) bool {
+		return stageNodes[i].name < stageNodes[j].name
+	})
+
+	if err := o.checkReferences(stageNodes); err != nil {
+		return err
+	}
+
+	sortedStages, cycle := toposort.Stable(stageNodes)
+	if cycle != nil {
+		return cycleError(stageNodes, cycle)
+	}
+
 	if _, err := fmt.Fprintf(w, "# syntax = %s\n\n", syntax); err != nil {
 		return err
 	}
@@ -107,24 +141,116 @@ func (o *Output) dockerfile(w io.Writer) error {
 		return err
 	}
 
-	stageNodes := make([]*Stage, 0, len(o.stages))
-	for _, stage := range o.stages {
-		stageNodes = append(stageNodes, stage)
+	for _, stageNode := range sortedStages {
+		if err := stageNode.Generate(w); err != nil {
+			return err
+		}
 	}
 
-	sort.Slice(stageNodes, func(i, j int) bool {
-		return stageNodes[i].name < stageNodes[j].name
+	return nil
+}
+
+// checkReferences verifies that every stage referenced via FROM or COPY --from is defined.
+func (o *Output) checkReferences(stageNodes []*Stage) error {
+	for _, stage := range stageNodes {
+		for _, dep := range stage.Dependencies() {
+			if !isStageReference(dep) {
+				continue
+			}
+
+			if stripVars.MatchString(dep) {
+				if !slices.ContainsFunc(stageNodes, func(other *Stage) bool { return matchesStage(dep, other.name) }) {
+					return fmt.Errorf("dockerfile stage %q references undefined stage %q", stage.name, dep)
+				}
+
+				continue
+			}
+
+			if _, ok := o.images[dep]; ok {
+				continue
+			}
+
+			if _, ok := o.stages[dep]; !ok {
+				return fmt.Errorf("dockerfile stage %q references undefined stage %q, use a fully qualified image reference if an image was meant", stage.name, dep)
+			}
+		}
+	}
+
+	return nil
+}
+
+// isStageReference returns true if FROM or COPY --from argument refers to a stage rather than to an image.
+//
+// Image references are recognized by the registry, tag or digest, so bare names are stages.
+func isStageReference(ref string) bool {
+	if ref == "" || ref == "scratch" || strings.ContainsAny(ref, "/:@") {
+		return false
+	}
+
+	// references fully made of variables are resolved at build time
+	return stripVars.ReplaceAllString(ref, "") != ""
+}
+
+// cycleError builds an error describing a dependency cycle between the stages.
+//
+// The cycle reported by the toposort also contains the stages depending on the cycle,
+// so the actual cycle is looked up among them.
+func cycleError(stageNodes, cycle []*Stage) error {
+	const (
+		unvisited = iota
+		inProgress
+		done
+	)
+
+	state := make(map[*Stage]int, len(stageNodes))
+
+	var (
+		path  []*Stage
+		visit func(*Stage) []*Stage
+	)
+
+	visit = func(stage *Stage) []*Stage {
+		state[stage] = inProgress
+		path = append(path, stage)
+
+		for _, dep := range stageNodes {
+			if !dep.Before(stage) {
+				continue
+			}
+
+			switch state[dep] {
+			case inProgress:
+				start := slices.Index(path, dep)
+
+				return append(slices.Clone(path[start:]), dep)
+			case unvisited:
+				if found := visit(dep); found != nil {
+					return found
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[stage] = done
+
+		return nil
+	}
+
+	sort.Slice(cycle, func(i, j int) bool {
+		return cycle[i].name < cycle[j].name
 	})
 
-	sortedStages, _ := toposort.Stable(stageNodes)
+	for _, stage := range cycle {
+		if state[stage] != unvisited {
+			continue
+		}
 
-	for _, stageNode := range sortedStages {
-		if err := stageNode.Generate(w); err != nil {
-			return err
+		if found := visit(stage); found != nil {
+			return fmt.Errorf("dockerfile stages form a dependency cycle: %s", strings.Join(xslices.Map(found, func(s *Stage) string { return s.name }), " -> "))
 		}
 	}
 
-	return nil
+	return fmt.Errorf("dockerfile stages form a dependency cycle: %s", strings.Join(xslices.Map(cycle, func(s *Stage) string { return s.name }), ", "))
 }
 
 // Compiler is implemented by project blocks which support Dockerfile generate.
@@ -33,10 +33,10 @@ func (suite *DockerfileSuite) TestGenerateFile() {
 
 	output.Stage("build").From("setup").Step(step.WorkDir("/src"))
 
-	output.Stage("foo").From("bar")
+	output.Stage("foo").From("bar:latest")
 
 	output.Stage("setup").From("scratch").Description("initialize tools").
-		Step(step.Copy("src", "/workdir/src").From("source")).
+		Step(step.Copy("src", "/workdir/src").From("ghcr.io/example/source:v1")).
 		Step(step.Copy(".", "."))
 
 	var buf bytes.Buffer
@@ -51,11 +51,11 @@ func (suite *DockerfileSuite) TestGenerateFile() {
 # Generated on 2006-01-02T15:04:05Z by test.
 
 
-FROM bar AS foo
+FROM bar:latest AS foo
 
 # initialize tools
 FROM scratch AS setup
-COPY --from=source src /workdir/src
+COPY --from=ghcr.io/example/source:v1 src /workdir/src
 COPY . .
 
 FROM setup AS build
@@ -64,6 +64,92 @@ WORKDIR /src
 `, config.DockerfileFrontendImageVersion), buf.String())
 }
 
+func (suite *DockerfileSuite) TestCycle() {
+	output := &dockerfile.Output{}
+
+	output.Stage("base").From("scratch")
+	output.Stage("a").From("base").Step(step.Copy("/", "/").From("c"))
+	output.Stage("b").From("a")
+	output.Stage("c").From("b")
+	output.Stage("d").From("c")
+
+	var buf bytes.Buffer
+
+	suite.Require().EqualError(output.GenerateFile("Dockerfile", &buf), "dockerfile stages form a dependency cycle: a -> c -> b -> a")
+}
+
+func (suite *DockerfileSuite) TestUndefinedStage() {
+	for _, test := range []struct {
+		name     string
+		build    func(*dockerfile.Output)
+		expected string
+	}{
+		{
+			name: "from",
+			build: func(output *dockerfile.Output) {
+				output.Stage("build").From("toolchain")
+			},
+			expected: `dockerfile stage "build" references undefined stage "toolchain", use a fully qualified image reference if an image was meant`,
+		},
+		{
+			name: "copy",
+			build: func(output *dockerfile.Output) {
+				output.Stage("base").From("docker.io/library/alpine:3")
+				output.Stage("image").From("scratch").Step(step.Copy("/", "/").From("generate"))
+			},
+			expected: `dockerfile stage "image" references undefined stage "generate", use a fully qualified image reference if an image was meant`,
+		},
+		{
+			name: "variable",
+			build: func(output *dockerfile.Output) {
+				output.Stage("image").From("build-${TARGETARCH}")
+			},
+			expected: `dockerfile stage "image" references undefined stage "build-${TARGETARCH}"`,
+		},
+		{
+			name: "variable suffix",
+			build: func(output *dockerfile.Output) {
+				output.Stage("amd64-build").From("scratch")
+				output.Stage("image").From("${TARGETARCH}-run")
+			},
+			expected: `dockerfile stage "image" references undefined stage "${TARGETARCH}-run"`,
+		},
+		{
+			name: "allowed image",
+			build: func(output *dockerfile.Output) {
+				output.AllowImage("alpine")
+				output.Stage("base").From("alpine")
+				output.Stage("image").From("scratch").Step(step.Copy("/", "/").From("alpine"))
+			},
+		},
+		{
+			name: "defined",
+			build: func(output *dockerfile.Output) {
+				output.Stage("build-amd64").From("${TOOLCHAIN}")
+				output.Stage("amd64-run").From("scratch")
+				output.Stage("image").From("build-${TARGETARCH}").Step(step.Copy("/", "/").From("build-amd64"))
+				output.Stage("run").From("${TARGETARCH}-run")
+			},
+		},
+	} {
+		suite.Run(test.name, func() {
+			output := &dockerfile.Output{}
+
+			test.build(output)
+
+			var buf bytes.Buffer
+
+			err := output.GenerateFile("Dockerfile", &buf)
+
+			if test.expected == "" {
+				suite.Require().NoError(err)
+			} else {
+				suite.Require().EqualError(err, test.expected)
+			}
+		})
+	}
+}
+
 func TestDockerfileSuite(t *testing.T) {
 	suite.Run(t, new(DockerfileSuite))
 }
@@ -79,9 +79,12 @@ func (stage *Stage) Dependencies() []string {
 // Before implements stableToposort.Node interface.
 func (stage *Stage) Before(otherStage *Stage) bool {
 	for _, dep := range otherStage.Dependencies() {
-		sanitized := stripVars.ReplaceAllString(dep, "")
-		if sanitized != dep && sanitized != "" {
-			return strings.HasPrefix(stage.name, sanitized)
+		if stripVars.MatchString(dep) {
+			if isStageReference(dep) && matchesStage(dep, stage.name) {
+				return true
+			}
+
+			continue
 		}
 
 		if dep == stage.name {
@@ -92,6 +95,27 @@ func (stage *Stage) Before(otherStage *Stage) bool {
 	return false
 }
 
+// matchesStage checks whether the reference built with variables (e.g. `build-${TARGETARCH}`) might resolve to the stage name.
+func matchesStage(ref, name string) bool {
+	var pattern strings.Builder
+
+	pattern.WriteString("^")
+
+	last := 0
+
+	for _, loc := range stripVars.FindAllStringIndex(ref, -1) {
+		pattern.WriteString(regexp.QuoteMeta(ref[last:loc[0]]))
+		pattern.WriteString(".*")
+
+		last = loc[1]
+	}
+
+	pattern.WriteString(regexp.QuoteMeta(ref[last:]))
+	pattern.WriteString("$")
+
+	return regexp.MustCompile(pattern.String()).MatchString(name)
+}
+
 // Generate renders Dockerfile to the output.
 func (stage *Stage) Generate(w io.Writer) error {
 	if stage.description != "" {
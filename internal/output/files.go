@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"errors"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -26,6 +27,12 @@ type FilePermissionsWriter interface {
 	Permissions(filename string) os.FileMode
 }
 
+// FileNoOverwriteWriter defines the files of a FileWriter which are only
+// generated if they don't exist yet. This interface is optional.
+type FileNoOverwriteWriter interface {
+	NoOverwrite(filename string) bool
+}
+
 // FileAdapter implements Writer via FileWriter.
 type FileAdapter struct {
 	FileWriter
@@ -36,22 +43,25 @@ var ErrSkip = errors.New("skip file")
 
 // Generate implements outout.Writer.
 //
-//nolint:gocognit
-func (adapter *FileAdapter) Generate() error {
+//nolint:gocognit,gocyclo,cyclop
+func (adapter *FileAdapter) Generate(fsys FS) error {
 	// buffer the output before writing it down
 	buffers := map[string]*bytes.Buffer{}
 
 	for _, filename := range adapter.Filenames() {
-		buf := bytes.NewBuffer(nil)
-
-		dir := filepath.Dir(filename)
+		if noOverwriteWriter, implements := adapter.FileWriter.(FileNoOverwriteWriter); implements && noOverwriteWriter.NoOverwrite(filename) {
+			_, err := fs.Stat(fsys, filename)
+			if err == nil {
+				continue
+			}
 
-		if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
-			if err := os.MkdirAll(dir, 0o755); err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
 				return err
 			}
 		}
 
+		buf := bytes.NewBuffer(nil)
+
 		if err := adapter.GenerateFile(filename, buf); err != nil {
 			if errors.Is(err, ErrSkip) {
 				continue
@@ -72,9 +82,9 @@ func (adapter *FileAdapter) Generate() error {
 		var oldContents []string
 
 		if err := func() error {
-			f, err := os.Open(filename)
+			f, err := fsys.Open(filename)
 			if err != nil {
-				if os.IsNotExist(err) {
+				if errors.Is(err, fs.ErrNotExist) {
 					return nil
 				}
 
@@ -96,18 +106,11 @@ func (adapter *FileAdapter) Generate() error {
 			continue // skip as no changes
 		}
 
-		if err := func() error {
-			f, err := os.Create(filename)
-			if err != nil {
-				return err
-			}
-
-			defer f.Close() //nolint:errcheck
-
-			_, err = buffers[filename].WriteTo(f)
-
+		if err := fsys.MkdirAll(filepath.Dir(filename), 0o755); err != nil {
 			return err
-		}(); err != nil {
+		}
+
+		if err := fsys.WriteFile(filename, buffers[filename].Bytes(), 0o644); err != nil {
 			return err
 		}
 
@@ -118,7 +121,7 @@ func (adapter *FileAdapter) Generate() error {
 				perms = 0o644
 			}
 
-			if err := os.Chmod(filename, perms); err != nil {
+			if err := fsys.Chmod(filename, perms); err != nil {
 				return err
 			}
 		}
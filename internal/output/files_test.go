@@ -0,0 +1,129 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package output_test
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/internal/output"
+)
+
+type staticFiles struct {
+	output.FileAdapter
+
+	files map[string]string
+}
+
+func newStaticFiles(files map[string]string) *staticFiles {
+	o := &staticFiles{
+		files: files,
+	}
+
+	o.FileWriter = o
+
+	return o
+}
+
+func (o *staticFiles) Filenames() []string {
+	result := make([]string, 0, len(o.files))
+
+	for filename := range o.files {
+		result = append(result, filename)
+	}
+
+	return result
+}
+
+func (o *staticFiles) GenerateFile(filename string, w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	_, err := w.Write([]byte(o.files[filename]))
+
+	return err
+}
+
+func (o *staticFiles) Permissions(filename string) os.FileMode {
+	if filename == "script.sh" {
+		return 0o744
+	}
+
+	return 0
+}
+
+func (o *staticFiles) NoOverwrite(filename string) bool {
+	return filename == "once"
+}
+
+func TestGenerate(t *testing.T) {
+	fsys := output.NewMemFS()
+
+	require.NoError(t, fsys.WriteFile("once", []byte("edited\n"), 0o644))
+	require.NoError(t, fsys.WriteFile("up-to-date", []byte("# old preamble\n\nfoo\n"), 0o644))
+
+	require.NoError(t, newStaticFiles(map[string]string{
+		"once":          "foo\n",
+		"up-to-date":    "foo\n",
+		"hack/new.yaml": "bar\n",
+		"script.sh":     "baz\n",
+	}).Generate(fsys))
+
+	for filename, expected := range map[string]string{
+		"once":          "edited\n",
+		"up-to-date":    "# old preamble\n\nfoo\n",
+		"hack/new.yaml": output.Preamble("# ") + "bar\n",
+		"script.sh":     output.Preamble("# ") + "baz\n",
+	} {
+		contents, err := fs.ReadFile(fsys, filename)
+		require.NoError(t, err)
+		assert.Equal(t, expected, string(contents), filename)
+	}
+
+	st, err := fs.Stat(fsys, "script.sh")
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o744), st.Mode())
+}
+
+func TestCheck(t *testing.T) {
+	base := output.NewMemFS()
+
+	require.NoError(t, base.WriteFile("up-to-date", []byte("# old preamble\n\nfoo\n"), 0o644))
+	require.NoError(t, base.WriteFile("stale", []byte("foo\nbar\n"), 0o644))
+	require.NoError(t, base.WriteFile("removed", []byte("foo\n"), 0o644))
+
+	var buf bytes.Buffer
+
+	checker := output.NewChecker(base, &buf)
+
+	require.NoError(t, newStaticFiles(map[string]string{
+		"up-to-date":  "foo\n",
+		"stale":       "foo\nbaz\n",
+		"new/missing": "foo\n",
+	}).Generate(checker))
+	require.NoError(t, checker.Remove("removed"))
+
+	assert.Equal(t, []string{"new/missing", "removed", "stale"}, checker.Drifted())
+	assert.Contains(t, buf.String(), "--- a/stale\n+++ b/stale\n@@ -1,2 +1,2 @@\n foo\n-bar\n+baz\n")
+	assert.Contains(t, buf.String(), "--- /dev/null\n+++ b/new/missing\n@@ -0,0 +1 @@\n+foo\n")
+	assert.Contains(t, buf.String(), "--- a/removed\n+++ /dev/null\n@@ -1 +0,0 @@\n-foo\n")
+
+	contents, err := fs.ReadFile(base, "stale")
+	require.NoError(t, err)
+	assert.Equal(t, "foo\nbar\n", string(contents))
+
+	_, err = fs.Stat(base, "new")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	_, err = fs.Stat(base, "removed")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,146 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package output
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"testing/fstest"
+)
+
+// FS is the filesystem outputs write generated files to.
+//
+// File names are relative to the project root.
+type FS interface {
+	fs.FS
+
+	MkdirAll(name string, perm fs.FileMode) error
+	WriteFile(name string, data []byte, perm fs.FileMode) error
+	Chmod(name string, mode fs.FileMode) error
+	Remove(name string) error
+}
+
+// OSFS returns FS backed by the directory dir on disk.
+func OSFS(dir string) FS {
+	return osFS{dir: dir}
+}
+
+type osFS struct {
+	dir string
+}
+
+func (o osFS) join(name string) string {
+	return filepath.Join(o.dir, filepath.FromSlash(name))
+}
+
+func (o osFS) Open(name string) (fs.File, error) {
+	return os.Open(o.join(name))
+}
+
+func (o osFS) MkdirAll(name string, perm fs.FileMode) error {
+	return os.MkdirAll(o.join(name), perm)
+}
+
+func (o osFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	return os.WriteFile(o.join(name), data, perm)
+}
+
+func (o osFS) Chmod(name string, mode fs.FileMode) error {
+	return os.Chmod(o.join(name), mode)
+}
+
+func (o osFS) Remove(name string) error {
+	return os.Remove(o.join(name))
+}
+
+// MemFS is an in-memory FS.
+//
+// Directories are created implicitly for every file written.
+type MemFS struct {
+	files fstest.MapFS
+}
+
+// NewMemFS creates an empty MemFS.
+func NewMemFS() *MemFS {
+	return &MemFS{
+		files: fstest.MapFS{},
+	}
+}
+
+func cleanPath(name string) string {
+	return path.Clean(filepath.ToSlash(name))
+}
+
+// Open implements fs.FS.
+func (m *MemFS) Open(name string) (fs.File, error) {
+	return m.files.Open(cleanPath(name))
+}
+
+// MkdirAll implements FS.
+func (m *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	name = cleanPath(name)
+
+	for dir := name; dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if file, ok := m.files[dir]; ok {
+			if !file.Mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: dir, Err: fs.ErrExist}
+			}
+
+			continue
+		}
+
+		m.files[dir] = &fstest.MapFile{Mode: fs.ModeDir | perm}
+	}
+
+	return nil
+}
+
+// WriteFile implements FS.
+//
+// As with os.WriteFile, perm is only used if the file doesn't exist yet.
+func (m *MemFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	name = cleanPath(name)
+
+	if file, ok := m.files[name]; ok {
+		if file.Mode.IsDir() {
+			return &fs.PathError{Op: "write", Path: name, Err: fs.ErrInvalid}
+		}
+
+		perm = file.Mode
+	}
+
+	m.files[name] = &fstest.MapFile{
+		Data: slices.Clone(data),
+		Mode: perm,
+	}
+
+	return nil
+}
+
+// Chmod implements FS.
+func (m *MemFS) Chmod(name string, mode fs.FileMode) error {
+	file, ok := m.files[cleanPath(name)]
+	if !ok {
+		return &fs.PathError{Op: "chmod", Path: name, Err: fs.ErrNotExist}
+	}
+
+	file.Mode = file.Mode.Type() | mode.Perm()
+
+	return nil
+}
+
+// Remove implements FS.
+func (m *MemFS) Remove(name string) error {
+	if _, ok := m.files[cleanPath(name)]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+
+	delete(m.files, cleanPath(name))
+
+	return nil
+}
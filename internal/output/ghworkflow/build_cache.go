@@ -0,0 +1,98 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ghworkflow
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/siderolabs/kres/internal/config"
+)
+
+const (
+	// BuildCacheGHA is the buildx GitHub Actions cache backend.
+	BuildCacheGHA = "gha"
+	// BuildCacheRegistry is the buildx registry cache backend.
+	BuildCacheRegistry = "registry"
+
+	// exposeActionsRuntimeScript exports the Actions runtime variables, which are only visible to JavaScript actions,
+	// to the following steps, so that buildx can access the GitHub Actions cache.
+	exposeActionsRuntimeScript = `
+for (const name of ['ACTIONS_RUNTIME_TOKEN', 'ACTIONS_CACHE_URL', 'ACTIONS_RESULTS_URL', 'ACTIONS_CACHE_SERVICE_V2']) {
+  core.exportVariable(name, process.env[name] || '');
+}
+`
+
+	setupBuildxStepID = "setup-buildx"
+)
+
+// SetBuildCache configures the jobs building with buildx to access the remote build cache backend.
+//
+// The steps required by the backend are inserted after the buildx setup step of every job:
+//   - gha: the Actions runtime token and cache URL are exposed to the build;
+//   - registry: the job logs in to the registry, the cache export is disabled for pull requests,
+//     as the pull requests from forks have no registry credentials.
+//
+// Other backends need no workflow support.
+func (o *Output) SetBuildCache(backend string) {
+	o.buildCache = backend
+}
+
+// buildCacheSteps returns the steps to be run after the buildx setup.
+func (o *Output) buildCacheSteps() []*JobStep {
+	switch o.buildCache {
+	case BuildCacheGHA:
+		return []*JobStep{
+			Step("Expose GitHub Actions runtime").
+				SetID("expose-actions-runtime").
+				SetUsesWithComment(
+					"actions/github-script@"+config.GitHubScriptActionRef,
+					"version: "+config.GitHubScriptActionVersion,
+				).
+				SetWith("script", strings.TrimPrefix(exposeActionsRuntimeScript, "\n")),
+		}
+	case BuildCacheRegistry:
+		disableExport := Step("Disable build cache export").
+			SetID("disable-cache-export").
+			SetCommand(`echo "CACHE_TO=" >> "${GITHUB_ENV}"`).
+			SetCustomCondition("github.event_name == 'pull_request'")
+
+		login := Step("Login to build cache registry").
+			SetID("login-build-cache").
+			SetUsesWithComment(
+				"docker/login-action@"+config.LoginActionRef,
+				"version: "+config.LoginActionVersion,
+			).
+			SetWith("registry", "ghcr.io").
+			SetWith("username", "${{ github.repository_owner }}").
+			SetWith("password", "${{ secrets.GITHUB_TOKEN }}").
+			SetCustomCondition("github.event_name != 'pull_request'")
+
+		return []*JobStep{disableExport, login}
+	default:
+		return nil
+	}
+}
+
+// addBuildCacheSteps inserts build cache steps into the jobs of the workflow which set up buildx.
+func (o *Output) addBuildCacheSteps(workflow *Workflow) {
+	steps := o.buildCacheSteps()
+	if len(steps) == 0 {
+		return
+	}
+
+	for _, job := range workflow.Jobs {
+		idx := slices.IndexFunc(job.Steps, func(s *JobStep) bool { return s.ID == setupBuildxStepID })
+		if idx == -1 || slices.ContainsFunc(job.Steps, func(s *JobStep) bool { return s.ID == steps[0].ID }) {
+			continue
+		}
+
+		job.Steps = slices.Insert(job.Steps, idx+1, steps...)
+
+		if o.buildCache == BuildCacheRegistry && job.Permissions != nil {
+			job.Permissions["packages"] = PermissionActionWrite
+		}
+	}
+}
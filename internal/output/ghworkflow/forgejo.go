@@ -0,0 +1,146 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ghworkflow
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/siderolabs/gen/maps"
+)
+
+const (
+	forgejoWorkflowDir = ".forgejo/workflows"
+
+	// ForgejoDefaultRunner is the Forgejo runner label used for the runner groups without explicit mapping.
+	ForgejoDefaultRunner = "docker"
+
+	githubScriptAction = "actions/github-script@"
+
+	// forgejoLabelRetrieveScript reads the PR labels from the event payload instead of the API used by [IssueLabelRetrieveScript].
+	//
+	// The labels are passed through the environment to avoid interpolating them into the shell command.
+	forgejoLabelRetrieveScript = `LABELS="$(printf '%s' "${PR_LABELS}" | tr -d '\n')"
+case "${LABELS}" in
+  ""|null) LABELS="[]" ;;
+esac
+echo "result=${LABELS}" >> "${GITHUB_OUTPUT}"`
+)
+
+// Forgejo configures the Forgejo Actions dialect of the workflows.
+//
+// Forgejo Actions differs from GitHub Actions in the following ways handled by the dialect:
+//   - workflows are written to .forgejo/workflows;
+//   - runner groups are replaced with Forgejo runner labels;
+//   - action references are resolved from ActionsURL mirror, if set;
+//   - Slack notify, lock and stale workflows are not generated, as they rely on workflow_run
+//     trigger and GitHub API;
+//   - PR labels are read from the event payload instead of github-script API lookup.
+//
+// Any other workflow_run trigger or github-script step fails the generation.
+type Forgejo struct {
+	// Runners maps GitHub runner groups to Forgejo runner labels.
+	//
+	// Groups not listed run on [ForgejoDefaultRunner].
+	Runners map[string]string
+
+	// ActionsURL is the base URL of the mirror to resolve the actions from, e.g. https://code.forgejo.org.
+	//
+	// If empty, Forgejo resolves the actions from its default actions URL.
+	ActionsURL string
+}
+
+// SetForgejo switches the output to Forgejo Actions dialect.
+func (o *Output) SetForgejo(forgejo Forgejo) {
+	o.forgejo = &forgejo
+}
+
+// dir returns the directory the workflows are written to.
+func (o *Output) dir() string {
+	if o.forgejo != nil {
+		return forgejoWorkflowDir
+	}
+
+	return workflowDir
+}
+
+// workflowKeys returns the workflows to be written.
+func (o *Output) workflowKeys() []string {
+	keys := maps.Keys(o.workflows)
+
+	if o.forgejo != nil {
+		keys = slices.DeleteFunc(keys, func(key string) bool {
+			return slices.Contains(githubOnlyWorkflows, key)
+		})
+	}
+
+	return keys
+}
+
+// filename maps the workflow key to the output file name.
+func (o *Output) filename(key string) string {
+	return o.dir() + strings.TrimPrefix(key, workflowDir)
+}
+
+// key maps the output file name to the workflow key.
+func (o *Output) key(filename string) string {
+	return workflowDir + strings.TrimPrefix(filename, o.dir())
+}
+
+// githubOnlyWorkflows are the workflows which are skipped in Forgejo dialect.
+var githubOnlyWorkflows = []string{slackWorkflow, SlackCIFailureWorkflow, lockWorkflow, staleWorkflow}
+
+// convert rewrites the workflow to Forgejo Actions dialect.
+func (forgejo *Forgejo) convert(filename string, workflow *Workflow) error {
+	if len(workflow.WorkFlowRun.Workflows) > 0 {
+		return fmt.Errorf("%s: workflow_run trigger is not supported by Forgejo Actions", filename)
+	}
+
+	jobNames := maps.Keys(workflow.Jobs)
+	slices.Sort(jobNames)
+
+	for _, jobName := range jobNames {
+		job := workflow.Jobs[jobName]
+
+		if groupLabel, ok := job.RunsOn.value.(RunsOnGroupLabel); ok {
+			runner := ForgejoDefaultRunner
+
+			if label, ok := forgejo.Runners[groupLabel.Group]; ok {
+				runner = label
+			}
+
+			job.RunsOn = NewRunsOnString(runner)
+		}
+
+		for i, step := range job.Steps {
+			if strings.HasPrefix(step.Uses.Image, githubScriptAction) {
+				if step.With["script"] != strings.TrimPrefix(IssueLabelRetrieveScript, "\n") {
+					return fmt.Errorf("%s: job %s step %q uses github-script which is not supported by Forgejo Actions", filename, jobName, step.Name)
+				}
+
+				job.Steps[i] = Step(step.Name).
+					SetID(step.ID).
+					SetEnv("PR_LABELS", "${{ toJSON(github.event.pull_request.labels.*.name) }}").
+					SetCommand(forgejoLabelRetrieveScript)
+
+				continue
+			}
+
+			step.Uses.Image = forgejo.actionRef(step.Uses.Image)
+		}
+	}
+
+	return nil
+}
+
+// actionRef rewrites the action reference to the mirror URL.
+func (forgejo *Forgejo) actionRef(ref string) string {
+	if forgejo.ActionsURL == "" || ref == "" || strings.HasPrefix(ref, "./") || strings.Contains(ref, "://") {
+		return ref
+	}
+
+	return strings.TrimSuffix(forgejo.ActionsURL, "/") + "/" + ref
+}
@@ -13,12 +13,11 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
-	"os"
 	"path/filepath"
 	"slices"
 	"strings"
 
-	"github.com/siderolabs/gen/maps"
+	"github.com/siderolabs/gen/xslices"
 	"go.yaml.in/yaml/v4"
 
 	"github.com/siderolabs/kres/internal/config"
@@ -90,6 +89,8 @@ done
 	// CiWorkflow is the default CI workflow.
 	CiWorkflow    = workflowDir + "/" + "ci.yaml"
 	slackWorkflow = workflowDir + "/" + "slack-notify.yaml"
+	lockWorkflow  = workflowDir + "/" + "lock.yml"
+	staleWorkflow = workflowDir + "/" + "stale.yml"
 	// SlackCIFailureWorkflowName is the name of the workflow to notify Slack on CI failure.
 	SlackCIFailureWorkflowName = "slack-notify-ci-failure"
 	// SlackCIFailureWorkflow is the Slack notify on CI failure workflow.
@@ -136,7 +137,9 @@ var (
 type Output struct {
 	output.FileAdapter
 
-	workflows map[string]*Workflow
+	workflows  map[string]*Workflow
+	forgejo    *Forgejo
+	buildCache string
 }
 
 // NewOutput creates new .github/workflows/ci.yaml output.
@@ -235,7 +238,7 @@ func NewOutput(mainBranch string, withDefaultJob, withStaleJob bool, slackChanne
 	}
 
 	if withStaleJob {
-		workflows[".github/workflows/lock.yml"] = &Workflow{
+		workflows[lockWorkflow] = &Workflow{
 			Name: "Lock old issues",
 			On: On{
 				Schedule: []Schedule{
@@ -268,7 +271,7 @@ func NewOutput(mainBranch string, withDefaultJob, withStaleJob bool, slackChanne
 			},
 		}
 
-		workflows[".github/workflows/stale.yml"] = &Workflow{
+		workflows[staleWorkflow] = &Workflow{
 			Name: "Close stale issues and PRs",
 			On: On{
 				Schedule: []Schedule{
@@ -371,35 +374,22 @@ func (o *Output) AddJob(name string, dispatch bool, job *Job, inputs []string) {
 }
 
 // dispatchableWorkflowFile returns the workflow file path for a dispatchable
-// job. The path is validated through [os.OpenRoot] against [workflowDir] so
-// the resulting file is guaranteed to live inside the workflows directory —
-// any name containing path separators, "..", or otherwise resolving outside
-// the root is rejected. It also guards against collisions with reserved
-// workflow filenames, which would overwrite managed workflows or nil-deref
-// when assigning workflow_dispatch inputs to a workflow that doesn't have
-// the dispatch trigger initialized.
+// job. The name must be a plain file name, so the resulting file is guaranteed
+// to live inside the workflows directory — any name containing path separators
+// or ".." is rejected. The name is validated without touching the filesystem,
+// the workflows directory is created by the output FS. It also guards against
+// collisions with reserved workflow filenames, which would overwrite managed
+// workflows or nil-deref when assigning workflow_dispatch inputs to a workflow
+// that doesn't have the dispatch trigger initialized.
 func dispatchableWorkflowFile(name string) string {
 	if name == "" {
 		panic("dispatchable job name must not be empty")
 	}
 
-	if err := os.MkdirAll(workflowDir, 0o755); err != nil {
-		panic(fmt.Sprintf("create %s: %v", workflowDir, err))
-	}
-
-	root, err := os.OpenRoot(workflowDir)
-	if err != nil {
-		panic(fmt.Sprintf("open %s as root: %v", workflowDir, err))
-	}
-	defer root.Close() //nolint:errcheck
-
 	relname := name + ".yaml"
 
-	// Root.Stat rejects names that escape the root (separators, "..",
-	// absolute paths, unsafe symlinks) without touching the filesystem
-	// beyond the safety check. fs.ErrNotExist is expected and acceptable.
-	if _, err := root.Stat(relname); err != nil && !errors.Is(err, fs.ErrNotExist) {
-		panic(fmt.Sprintf("dispatchable job name %q is not a valid filename: %v", name, err))
+	if !filepath.IsLocal(relname) || strings.ContainsAny(name, `/\`) {
+		panic(fmt.Sprintf("dispatchable job name %q is not a valid filename", name))
 	}
 
 	file := filepath.Join(workflowDir, relname)
@@ -464,6 +454,17 @@ func (o *Output) AddJobPermissions(jobName, permission, value string) {
 	o.workflows[CiWorkflow].Jobs[jobName].Permissions[permission] = PermissionAction(value)
 }
 
+// AddJobNeeds adds the jobs the job depends on.
+func (o *Output) AddJobNeeds(jobName string, needs ...string) {
+	job := o.workflows[CiWorkflow].Jobs[jobName]
+
+	for _, need := range needs {
+		if !slices.Contains(job.Needs, need) {
+			job.Needs = append(job.Needs, need)
+		}
+	}
+}
+
 // AddStepBefore adds step before another step in the job.
 func (o *Output) AddStepBefore(jobName, beforeStepID string, steps ...*JobStep) {
 	job := o.workflows[CiWorkflow].Jobs[jobName]
@@ -927,24 +928,24 @@ func (o *Output) Compile(compiler Compiler) error {
 }
 
 // Generate extends FileAdapter.Generate by removing stale kres-generated
-// workflow files in .github/workflows/ that are no longer part of the current
+// workflow files in the workflows directory that are no longer part of the current
 // configuration. Files are considered kres-generated only when [isKresGenerated]
 // finds a kres-specific marker in the preamble (current or legacy + the kres
 // generator tag), so user-managed or non-kres workflows are left untouched.
-func (o *Output) Generate() error {
-	if err := o.FileAdapter.Generate(); err != nil {
+func (o *Output) Generate(fsys output.FS) error {
+	if err := o.FileAdapter.Generate(fsys); err != nil {
 		return err
 	}
 
 	// Build a set of every workflow file this run manages.
 	managed := make(map[string]struct{}, len(o.workflows))
-	for f := range o.workflows {
+	for _, f := range o.Filenames() {
 		managed[f] = struct{}{}
 	}
 
-	entries, err := os.ReadDir(workflowDir)
+	entries, err := fs.ReadDir(fsys, o.dir())
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			return nil
 		}
 
@@ -961,12 +962,12 @@ func (o *Output) Generate() error {
 			continue
 		}
 
-		fullPath := filepath.Join(workflowDir, name)
+		fullPath := filepath.Join(o.dir(), name)
 		if _, ok := managed[fullPath]; ok {
 			continue
 		}
 
-		generated, err := isKresGenerated(fullPath)
+		generated, err := isKresGenerated(fsys, fullPath)
 		if err != nil {
 			return err
 		}
@@ -975,7 +976,7 @@ func (o *Output) Generate() error {
 			continue
 		}
 
-		if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		if err := fsys.Remove(fullPath); err != nil && !errors.Is(err, fs.ErrNotExist) {
 			return err
 		}
 	}
@@ -992,10 +993,10 @@ func (o *Output) Generate() error {
 //     together with the [output.PreambleCreatorTag] generator tag — the
 //     legacy marker text is also used by other code generators, so the kres
 //     tag is required to disambiguate and avoid deleting non-kres files.
-func isKresGenerated(path string) (bool, error) {
-	f, err := os.Open(path)
+func isKresGenerated(fsys fs.FS, path string) (bool, error) {
+	f, err := fsys.Open(path)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if errors.Is(err, fs.ErrNotExist) {
 			return false, nil
 		}
 
@@ -1026,7 +1027,7 @@ func isKresGenerated(path string) (bool, error) {
 
 // Filenames implements output.FileWriter interface.
 func (o *Output) Filenames() []string {
-	return maps.Keys(o.workflows)
+	return xslices.Map(o.workflowKeys(), o.filename)
 }
 
 // GenerateFile implements output.FileWriter interface.
@@ -1034,7 +1035,17 @@ func (o *Output) GenerateFile(filename string, w io.Writer) error {
 	return o.ghWorkflow(w, filename)
 }
 
-func (o *Output) ghWorkflow(w io.Writer, name string) error {
+func (o *Output) ghWorkflow(w io.Writer, filename string) error {
+	name := o.key(filename)
+
+	o.addBuildCacheSteps(o.workflows[name])
+
+	if o.forgejo != nil {
+		if err := o.forgejo.convert(filename, o.workflows[name]); err != nil {
+			return err
+		}
+	}
+
 	preamble := output.Preamble("# ")
 
 	if _, err := w.Write([]byte(preamble)); err != nil {
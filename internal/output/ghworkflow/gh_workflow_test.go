@@ -6,16 +6,20 @@ package ghworkflow_test
 
 import (
 	"bytes"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/siderolabs/gen/xslices"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/siderolabs/kres/internal/config"
 	"github.com/siderolabs/kres/internal/output"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
 )
@@ -208,3 +212,110 @@ func TestMatrixStrategy(t *testing.T) {
 	require.NoError(t, o.GenerateFile(".github/workflows/integration-provision-triggered.yaml", &buf))
 	assertGolden(t, "integration-provision-triggered.yaml", buf.Bytes())
 }
+
+func TestForgejo(t *testing.T) {
+	output.PreambleTimestamp, _ = time.Parse(time.RFC3339, strings.ReplaceAll(time.RFC3339, "07:00", "")) //nolint:errcheck
+	output.PreambleCreator = "test"
+
+	o := ghworkflow.NewOutput("main", true, true, "")
+	o.SetForgejo(ghworkflow.Forgejo{
+		ActionsURL: "https://code.forgejo.org/",
+		Runners:    map[string]string{ghworkflow.PkgsRunner: "large"},
+	})
+	o.SetOptionsForPkgs(false)
+	o.AddStepInParallelJob("lint", ghworkflow.GenericRunner, nil, ghworkflow.Step("lint").SetMakeStep("lint"))
+	o.AddStep(
+		ghworkflow.DefaultJobName,
+		ghworkflow.Step("Retrieve PR labels").
+			SetID("retrieve-pr-labels").
+			SetUsesWithComment("actions/github-script@"+config.GitHubScriptActionRef, "version: "+config.GitHubScriptActionVersion).
+			SetWith("script", strings.TrimPrefix(ghworkflow.IssueLabelRetrieveScript, "\n")),
+	)
+
+	fsys := output.NewMemFS()
+
+	require.NoError(t, o.Generate(fsys))
+
+	entries, err := fs.ReadDir(fsys, ".")
+	require.NoError(t, err)
+	assert.Equal(t, []string{".forgejo"}, xslices.Map(entries, fs.DirEntry.Name))
+
+	entries, err = fs.ReadDir(fsys, ".forgejo/workflows")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ci.yaml"}, xslices.Map(entries, fs.DirEntry.Name))
+
+	ci, err := fs.ReadFile(fsys, ".forgejo/workflows/ci.yaml")
+	require.NoError(t, err)
+	assertGolden(t, "ci.yaml", ci)
+}
+
+func TestDispatchableJob(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	o := ghworkflow.NewOutput("main", true, false, "")
+	o.SetRunnerGroup(ghworkflow.GenericRunner)
+	o.AddJob("deploy", true, &ghworkflow.Job{RunsOn: ghworkflow.NewRunsOnGroupLabel(ghworkflow.GenericRunner, "")}, []string{"version"})
+
+	fsys := output.NewMemFS()
+
+	require.NoError(t, o.Generate(fsys))
+
+	_, err := fs.Stat(fsys, ".github/workflows/deploy.yaml")
+	require.NoError(t, err)
+
+	// the workflows are written only to the output FS
+	_, err = os.Stat(".github")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	for _, name := range []string{"../deploy", "sub/deploy", "ci"} {
+		assert.Panics(t, func() { o.AddJob(name, true, &ghworkflow.Job{}, nil) }, name)
+	}
+}
+
+func TestForgejoUnsupported(t *testing.T) {
+	o := ghworkflow.NewOutput("main", true, false, "")
+	o.SetForgejo(ghworkflow.Forgejo{})
+	o.AddStep(
+		ghworkflow.DefaultJobName,
+		ghworkflow.Step("Comment").
+			SetUsesWithComment("actions/github-script@"+config.GitHubScriptActionRef, "").
+			SetWith("script", "github.rest.issues.createComment()"),
+	)
+
+	assert.EqualError(t, o.GenerateFile(".forgejo/workflows/ci.yaml", io.Discard),
+		`.forgejo/workflows/ci.yaml: job default step "Comment" uses github-script which is not supported by Forgejo Actions`)
+
+	o = ghworkflow.NewOutput("main", false, false, "")
+	o.SetForgejo(ghworkflow.Forgejo{})
+	o.AddWorkflow("triggered", &ghworkflow.Workflow{
+		Name: "triggered",
+		On: ghworkflow.On{
+			WorkFlowRun: ghworkflow.WorkFlowRun{
+				Workflows: []string{"default"},
+			},
+		},
+	})
+
+	assert.EqualError(t, o.GenerateFile(".forgejo/workflows/triggered.yaml", io.Discard),
+		".forgejo/workflows/triggered.yaml: workflow_run trigger is not supported by Forgejo Actions")
+}
+
+func TestBuildCache(t *testing.T) {
+	output.PreambleTimestamp, _ = time.Parse(time.RFC3339, strings.ReplaceAll(time.RFC3339, "07:00", "")) //nolint:errcheck
+	output.PreambleCreator = "test"
+
+	for _, backend := range []string{ghworkflow.BuildCacheGHA, ghworkflow.BuildCacheRegistry} {
+		t.Run(backend, func(t *testing.T) {
+			o := ghworkflow.NewOutput("main", true, false, "")
+			o.SetRunnerGroup(ghworkflow.GenericRunner)
+			o.SetBuildCache(backend)
+			o.AddStep(ghworkflow.DefaultJobName, ghworkflow.Step("base").SetMakeStep("base"))
+			o.AddStepInParallelJob("lint", ghworkflow.GenericRunner, nil, ghworkflow.Step("lint").SetMakeStep("lint"))
+
+			var buf bytes.Buffer
+
+			require.NoError(t, o.GenerateFile(".github/workflows/ci.yaml", &buf))
+			assertGolden(t, "ci.yaml", buf.Bytes())
+		})
+	}
+}
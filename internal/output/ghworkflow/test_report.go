@@ -0,0 +1,63 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package ghworkflow
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/siderolabs/kres/internal/config"
+)
+
+// testSummaryScript adds a table with the totals of the JUnit XML reports to the job summary.
+//
+// The totals are summed up over the `<testsuite>` elements, so that the reports of any test runner are supported.
+const testSummaryScript = `
+{
+  echo "| Report | Tests | Failures | Errors | Skipped |"
+  echo "| --- | ---: | ---: | ---: | ---: |"
+  for report in %s; do
+    if [ -f "${report}" ]; then
+      { grep -o '<testsuite [^>]*>' "${report}" || true; } | awk -v report="${report##*/}" '
+        function attr(name) { return match($0, " " name "=\"[0-9]+\"") ? substr($0, RSTART + length(name) + 3, RLENGTH - length(name) - 4) : 0 }
+        { tests += attr("tests"); failures += attr("failures"); errors += attr("errors"); skipped += attr("skipped") }
+        END { printf "| %%s | %%d | %%d | %%d | %%d |\n", report, tests, failures, errors, skipped }'
+    fi
+  done
+} >> "${GITHUB_STEP_SUMMARY}"
+`
+
+// TestReportSteps returns the steps uploading the JUnit XML reports as the artifact and adding the test summary to the job.
+//
+// The steps are run even if the tests fail.
+func TestReportSteps(name string, reports ...string) []*JobStep {
+	upload := Step("save-"+name).
+		SetUsesWithComment(
+			"actions/upload-artifact@"+config.UploadArtifactActionRef,
+			"version: "+config.UploadArtifactActionVersion,
+		).
+		SetWith("name", name).
+		SetWith("path", strings.Join(reports, "\n")).
+		SetWith("retention-days", "5")
+
+	quoted := make([]string, 0, len(reports))
+
+	for _, report := range reports {
+		quoted = append(quoted, strconv.Quote(filepath.ToSlash(report)))
+	}
+
+	summary := Step("test-summary").
+		SetCommand(strings.Trim(fmt.Sprintf(testSummaryScript, strings.Join(quoted, " ")), "\n"))
+
+	steps := []*JobStep{upload, summary}
+
+	for _, step := range steps {
+		step.appendIf("always()")
+	}
+
+	return steps
+}
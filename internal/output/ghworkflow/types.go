@@ -205,10 +205,11 @@ func NewRunsOnGroupLabel(group, label string) RunsOn {
 
 // Service represents GitHub Actions service.
 type Service struct {
-	Image   string   `yaml:"image"`
-	Options string   `yaml:"options,omitempty"`
-	Ports   []string `yaml:"ports,omitempty"`
-	Volumes []string `yaml:"volumes,omitempty"`
+	Image   string            `yaml:"image"`
+	Env     map[string]string `yaml:"env,omitempty"`
+	Options string            `yaml:"options,omitempty"`
+	Ports   []string          `yaml:"ports,omitempty"`
+	Volumes []string          `yaml:"volumes,omitempty"`
 }
 
 // JobStep represents GitHub Actions job step.
@@ -44,7 +44,7 @@ func (t *trackingWriter[T]) Compile(v T) error {
 	return t.writer.Compile(v)
 }
 
-func (t *trackingWriter[T]) Generate() error {
+func (t *trackingWriter[T]) Generate(fsys output.FS) error {
 	switch source := t.writer.(type) {
 	case managedFilenamer:
 		t.output.MarkGenerated(source.ManagedFilenames()...)
@@ -52,5 +52,5 @@ func (t *trackingWriter[T]) Generate() error {
 		t.output.MarkGenerated(source.Filenames()...)
 	}
 
-	return t.writer.Generate()
+	return t.writer.Generate(fsys)
 }
@@ -10,16 +10,23 @@ import (
 	"os"
 
 	"github.com/google/go-github/v88/github"
+
+	"github.com/siderolabs/kres/internal/output"
 )
 
 // Output implements interface to GitHub API.
 type Output struct {
 	client *github.Client
+	dryRun bool
 }
 
 // NewOutput creates new GitHub API output.
-func NewOutput() *Output {
-	output := &Output{}
+//
+// When dryRun is set, the compilers only report the changes they would apply via the API.
+func NewOutput(dryRun bool) *Output {
+	output := &Output{
+		dryRun: dryRun,
+	}
 
 	token, exists := os.LookupEnv("GITHUB_TOKEN")
 	if !exists {
@@ -41,20 +48,29 @@ func NewOutput() *Output {
 }
 
 // Generate implements Output interface.
-func (o *Output) Generate() error {
+func (o *Output) Generate(output.FS) error {
 	// GitHub API does all the work in Compile, so this method does nothing.
 	return nil
 }
 
-// Compile implements [output.TypedWriter] interface. The client may be nil
-// when GITHUB_TOKEN is not set, which disables GitHub API integration. The
-// nil client is forwarded to the compiler as-is; dry-run behavior must be
-// opted into explicitly by the compiler.
+// Compile implements [output.TypedWriter] interface.
 func (o *Output) Compile(compiler Compiler) error {
-	return compiler.CompileGitHub(o.client)
+	return compiler.CompileGitHub(o)
+}
+
+// Client returns the GitHub API client. The client may be nil when
+// GITHUB_TOKEN is not set, which disables GitHub API integration unless
+// dry-run behavior is opted into by the output or by the compiler.
+func (o *Output) Client() *github.Client {
+	return o.client
+}
+
+// DryRun reports whether the API changes should only be reported.
+func (o *Output) DryRun() bool {
+	return o.dryRun
 }
 
 // Compiler is implemented by project blocks which support GitHub API interface.
 type Compiler interface {
-	CompileGitHub(*github.Client) error
+	CompileGitHub(*Output) error
 }
@@ -0,0 +1,417 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package gitlabci implements output to .gitlab-ci.yml.
+package gitlabci
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"go.yaml.in/yaml/v4"
+
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/output"
+	"github.com/siderolabs/kres/internal/output/ghworkflow"
+)
+
+const (
+	configFile = ".gitlab-ci.yml"
+
+	// DefaultJobName is the name of the default job.
+	//
+	// GitLab CI reserves "default" keyword, so the default job is named after its stage.
+	DefaultJobName = "build"
+
+	// StageBuild is the stage of the default job.
+	StageBuild = "build"
+	// StageTest is the stage of the jobs running after the default job.
+	StageTest = "test"
+	// StageRelease is the stage of the release jobs.
+	StageRelease = "release"
+
+	// ScheduleVariable is the pipeline schedule variable selecting the scheduled job to run.
+	ScheduleVariable = "SCHEDULE"
+
+	// MainBranchVariable is the pipeline variable holding the main branch of the project.
+	MainBranchVariable = "MAIN_BRANCH"
+)
+
+// Output implements GitLab CI config generation.
+type Output struct {
+	output.FileAdapter
+
+	pipeline  *Pipeline
+	schedules map[string][]string
+}
+
+// NewOutput creates new .gitlab-ci.yml output.
+func NewOutput(mainBranch string) *Output {
+	output := &Output{
+		pipeline: &Pipeline{
+			Workflow: &Workflow{
+				Rules: []Rule{
+					{If: `$CI_PIPELINE_SOURCE == "merge_request_event"`},
+					{If: `$CI_PIPELINE_SOURCE == "schedule"`},
+					{If: `$CI_COMMIT_TAG =~ /^v/`},
+					{If: fmt.Sprintf(`$CI_COMMIT_BRANCH == %q || $CI_COMMIT_BRANCH =~ /^release-/`, mainBranch)},
+				},
+			},
+			Stages: []string{StageBuild, StageTest, StageRelease},
+			Default: &Default{
+				Image:    "docker:" + config.DockerContainerImageVersion,
+				Services: []string{"docker:" + config.DindContainerImageVersion},
+				BeforeScript: []string{
+					"apk add --no-cache bash git make",
+					"docker buildx create --driver docker-container --use",
+				},
+			},
+			Variables: map[string]string{
+				"DOCKER_HOST":        "tcp://docker:2376",
+				"DOCKER_TLS_CERTDIR": "/certs",
+				"DOCKER_TLS_VERIFY":  "1",
+				"DOCKER_CERT_PATH":   "/certs/client",
+				"GIT_DEPTH":          "0",
+				MainBranchVariable:   mainBranch,
+				"REGISTRY":           "$CI_REGISTRY",
+				"USERNAME":           "$CI_PROJECT_NAMESPACE",
+			},
+			Jobs: map[string]*Job{
+				DefaultJobName: {
+					Stage: StageBuild,
+				},
+			},
+		},
+		schedules: map[string][]string{},
+	}
+
+	output.FileWriter = output
+
+	return output
+}
+
+// Compile implements [output.TypedWriter] interface.
+func (o *Output) Compile(compiler Compiler) error {
+	return compiler.CompileGitLabCI(o)
+}
+
+// SetBuildCacheRegistry configures the jobs to access the registry build cache.
+//
+// Every job logs in to the cache registry with the job token, the cache export is disabled for merge requests,
+// as the merge requests from forks can't push to the project registry.
+func (o *Output) SetBuildCacheRegistry(registry string) {
+	disableExport := Command("export CACHE_TO=")
+	disableExport.conditions = append(disableExport.conditions, shellConditions["on-pull-request"])
+
+	o.pipeline.Default.BeforeScript = append(o.pipeline.Default.BeforeScript,
+		loginStep(fmt.Sprintf("%q", registry)).String(),
+		disableExport.String(),
+	)
+}
+
+// AddJob adds a job to the pipeline.
+func (o *Output) AddJob(name string, job *Job) {
+	o.pipeline.Jobs[name] = job
+}
+
+// AddStep adds steps to the job script.
+func (o *Output) AddStep(jobName string, steps ...*Step) {
+	job := o.pipeline.Jobs[jobName]
+
+	for _, step := range steps {
+		job.Script = append(job.Script, step.String())
+	}
+}
+
+// AddStepInParallelJob adds steps to a job running after the default job on merge requests.
+//
+// If needsOverride is provided, the job will depend on the specified jobs instead of the default job.
+func (o *Output) AddStepInParallelJob(jobName string, needsOverride []string, steps ...*Step) {
+	if o.pipeline.Jobs[jobName] == nil {
+		needs := needsOverride
+		if len(needs) == 0 {
+			needs = []string{DefaultJobName}
+		}
+
+		o.pipeline.Jobs[jobName] = &Job{
+			Stage: StageTest,
+			Needs: NeedJobs(needs...),
+			Rules: []Rule{
+				{If: ruleConditions["on-pull-request"]},
+			},
+		}
+	}
+
+	o.AddStep(jobName, steps...)
+}
+
+// AddArtifacts adds paths to the job artifacts.
+func (o *Output) AddArtifacts(jobName, expireIn string, paths ...string) {
+	job := o.pipeline.Jobs[jobName]
+
+	if job.Artifacts == nil {
+		job.Artifacts = &Artifacts{
+			ExpireIn: "5 days",
+		}
+	}
+
+	if expireIn != "" {
+		job.Artifacts.ExpireIn = expireIn
+	}
+
+	for _, path := range paths {
+		if path != "" && !slices.Contains(job.Artifacts.Paths, path) {
+			job.Artifacts.Paths = append(job.Artifacts.Paths, path)
+		}
+	}
+}
+
+// SetArtifactsAlways makes the job upload artifacts even if the job fails.
+func (o *Output) SetArtifactsAlways(jobName string) {
+	o.pipeline.Jobs[jobName].Artifacts.When = "always"
+}
+
+// AddTestReports adds JUnit XML reports to the job artifacts, the reports are uploaded even if the tests fail.
+func (o *Output) AddTestReports(jobName string, reports ...string) {
+	o.AddArtifacts(jobName, "")
+	o.SetArtifactsAlways(jobName)
+
+	artifacts := o.pipeline.Jobs[jobName].Artifacts
+
+	if artifacts.Reports == nil {
+		artifacts.Reports = &ArtifactsReports{}
+	}
+
+	for _, report := range reports {
+		if !slices.Contains(artifacts.Reports.JUnit, report) {
+			artifacts.Reports.JUnit = append(artifacts.Reports.JUnit, report)
+		}
+	}
+}
+
+// SetParallel runs the number of the job instances in parallel, each instance gets its index in CI_NODE_INDEX variable.
+func (o *Output) SetParallel(jobName string, instances int) {
+	o.pipeline.Jobs[jobName].Parallel = instances
+}
+
+// AddJobNeeds adds dependencies to the job.
+func (o *Output) AddJobNeeds(jobName string, needs ...string) {
+	job := o.pipeline.Jobs[jobName]
+
+	for _, need := range NeedJobs(needs...) {
+		if !slices.Contains(job.Needs, need) {
+			job.Needs = append(job.Needs, need)
+		}
+	}
+}
+
+// AddScheduledJob adds a job which runs only in the pipeline schedules selecting it.
+//
+// GitLab pipeline schedules are not part of .gitlab-ci.yml, so crons are listed in the file header
+// to be set up in the project settings with SCHEDULE variable set to the job name.
+func (o *Output) AddScheduledJob(name string, crons []string, job *Job) {
+	job.Rules = append(job.Rules, Rule{
+		If: fmt.Sprintf(`$CI_PIPELINE_SOURCE == "schedule" && $%s == %q`, ScheduleVariable, name),
+	})
+
+	o.schedules[name] = append(o.schedules[name], crons...)
+	o.AddJob(name, job)
+}
+
+// NeedJobs builds the list of job dependencies fetching their artifacts.
+//
+// GitHub Actions default job name is mapped to [DefaultJobName], so that the config written for GitHub Actions can be reused.
+func NeedJobs(jobs ...string) []Need {
+	needs := make([]Need, 0, len(jobs))
+
+	for _, job := range jobs {
+		if job == "default" {
+			job = DefaultJobName
+		}
+
+		needs = append(needs, Need{Job: job})
+	}
+
+	return needs
+}
+
+// Filenames implements output.FileWriter interface.
+func (o *Output) Filenames() []string {
+	return []string{configFile}
+}
+
+// GenerateFile implements output.FileWriter interface.
+func (o *Output) GenerateFile(filename string, w io.Writer) error {
+	switch filename {
+	case configFile:
+		return o.gitlabCI(w)
+	default:
+		panic("unexpected filename: " + filename)
+	}
+}
+
+func (o *Output) gitlabCI(w io.Writer) error {
+	if _, err := w.Write([]byte(output.Preamble("# "))); err != nil {
+		return err
+	}
+
+	if len(o.schedules) > 0 {
+		if _, err := fmt.Fprintf(w, "# Pipeline schedules to set up in the project settings (with %s variable set to the job name):\n", ScheduleVariable); err != nil {
+			return err
+		}
+
+		for _, name := range slices.Sorted(maps.Keys(o.schedules)) {
+			for _, cron := range o.schedules[name] {
+				if _, err := fmt.Fprintf(w, "#   %s: %s\n", name, cron); err != nil {
+					return err
+				}
+			}
+		}
+
+		if _, err := fmt.Fprintln(w); err != nil {
+			return err
+		}
+	}
+
+	// script lines are long shell commands, so they are kept unwrapped
+	out, err := yaml.Dump(o.pipeline, yaml.WithV3Defaults(), yaml.WithIndent(2), yaml.WithLineWidth(-1))
+	if err != nil {
+		return fmt.Errorf("failed to encode pipeline: %w", err)
+	}
+
+	_, err = w.Write(out)
+
+	return err
+}
+
+// Step is a single command of the job script.
+type Step struct {
+	env        map[string]string
+	command    string
+	conditions []string
+}
+
+// MakeStep creates a step running make command.
+func MakeStep(target string, args ...string) *Step {
+	return Command(strings.Join(slices.Concat([]string{"make"}, slices.DeleteFunc([]string{target}, func(s string) bool { return s == "" }), args), " "))
+}
+
+// Command creates a step running shell command.
+func Command(command string) *Step {
+	return &Step{
+		command: command,
+	}
+}
+
+// LoginStep creates a step logging in to the project container registry.
+func LoginStep() *Step {
+	return loginStep(`"${CI_REGISTRY}"`)
+}
+
+func loginStep(registry string) *Step {
+	return Command(`echo "${CI_REGISTRY_PASSWORD}" | docker login -u "${CI_REGISTRY_USER}" --password-stdin ` + registry)
+}
+
+// SetEnv sets step environment variables.
+func (step *Step) SetEnv(name, value string) *Step {
+	if step.env == nil {
+		step.env = map[string]string{}
+	}
+
+	step.env[name] = value
+
+	return step
+}
+
+// SetConditions sets step conditions.
+//
+// Conditions use the same keywords as GitHub Actions steps, e.g. "on-pull-request" or "only-on-tag".
+func (step *Step) SetConditions(conditions ...string) error {
+	for _, condition := range conditions {
+		if condition == "" {
+			continue
+		}
+
+		test, ok := shellConditions[condition]
+		if !ok {
+			return fmt.Errorf("unsupported condition for GitLab CI: %s", condition)
+		}
+
+		step.conditions = append(step.conditions, test)
+	}
+
+	return nil
+}
+
+// SetConditionOnlyOnBranch adds condition to run step only on a specific branch name.
+func (step *Step) SetConditionOnlyOnBranch(name string) *Step {
+	step.conditions = append(step.conditions, fmt.Sprintf(`[ "${CI_COMMIT_BRANCH}" = %q ]`, name))
+
+	return step
+}
+
+// String renders the step as a script line.
+func (step *Step) String() string {
+	command := step.command
+
+	if len(step.env) > 0 {
+		assignments := make([]string, 0, len(step.env))
+
+		for _, name := range slices.Sorted(maps.Keys(step.env)) {
+			assignments = append(assignments, name+"="+shellQuote(step.env[name]))
+		}
+
+		command = strings.Join(assignments, " ") + " " + command
+	}
+
+	if len(step.conditions) == 0 {
+		return command
+	}
+
+	return fmt.Sprintf("if %s; then %s; fi", strings.Join(step.conditions, " && "), command)
+}
+
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"$`\\|&;<>()*?[]#~{}") {
+		return s
+	}
+
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Compiler is implemented by project blocks which support GitLab CI config generation.
+type Compiler interface {
+	CompileGitLabCI(*Output) error
+}
+
+// Guard creates a writer failing the generation for the project blocks which are compiled
+// to GitHub Actions workflows but not to GitLab CI, so that they are not silently dropped from the pipeline.
+func Guard() output.Writer {
+	return guard{}
+}
+
+type guard struct{}
+
+func (guard) Generate(output.FS) error { return nil }
+
+func (guard) Compile(node any) error {
+	if _, ok := node.(ghworkflow.Compiler); !ok {
+		return nil
+	}
+
+	if _, ok := node.(Compiler); ok {
+		return nil
+	}
+
+	name := fmt.Sprintf("%T", node)
+
+	if named, ok := node.(interface{ Name() string }); ok {
+		name = named.Name()
+	}
+
+	return fmt.Errorf("%s is not supported with GitLab CI", name)
+}
@@ -0,0 +1,152 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gitlabci_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/siderolabs/kres/internal/output"
+	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
+)
+
+// set to true to regenerate testdata golden files.
+var update = false //nolint:gochecknoglobals
+
+func assertGolden(t testing.TB, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", filepath.FromSlash(t.Name()), ".gitlab-ci.yml")
+
+	if update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o600))
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+type GitLabCISuite struct {
+	suite.Suite
+}
+
+func TestGitLabCISuite(t *testing.T) {
+	suite.Run(t, new(GitLabCISuite))
+}
+
+func (suite *GitLabCISuite) SetupSuite() {
+	output.PreambleTimestamp, _ = time.Parse(time.RFC3339, strings.ReplaceAll(time.RFC3339, "07:00", "")) //nolint:errcheck
+	output.PreambleCreator = "test"
+}
+
+func (suite *GitLabCISuite) generate(o *gitlabci.Output) []byte {
+	var buf bytes.Buffer
+
+	suite.Require().NoError(o.GenerateFile(".gitlab-ci.yml", &buf))
+
+	return buf.Bytes()
+}
+
+func (suite *GitLabCISuite) TestDefault() {
+	o := gitlabci.NewOutput("main")
+
+	pushStep := gitlabci.MakeStep("image-kres").SetEnv("PUSH", "true")
+	suite.Require().NoError(pushStep.SetConditions("except-pull-request"))
+
+	o.AddStep(gitlabci.DefaultJobName, gitlabci.MakeStep("base"), pushStep)
+	o.AddArtifacts(gitlabci.DefaultJobName, "", "_out")
+
+	o.AddStepInParallelJob("lint", nil, gitlabci.MakeStep("lint"))
+	o.AddStepInParallelJob("e2e", []string{"default", "lint"}, gitlabci.MakeStep("e2e", "E2E_ARGS=--fast"))
+	o.AddArtifacts("e2e", "3 days", "_out/logs")
+	o.SetArtifactsAlways("e2e")
+
+	assertGolden(suite.T(), suite.generate(o))
+}
+
+func (suite *GitLabCISuite) TestScheduledJob() {
+	o := gitlabci.NewOutput("main")
+
+	o.AddStep(gitlabci.DefaultJobName, gitlabci.MakeStep("base"))
+	o.AddScheduledJob("integration-cron", []string{"30 1 * * *", "30 13 * * *"}, &gitlabci.Job{
+		Stage: gitlabci.StageBuild,
+	})
+	o.AddStep("integration-cron", gitlabci.MakeStep("integration").SetEnv("WITH_RUNTIME", "a b"))
+
+	assertGolden(suite.T(), suite.generate(o))
+}
+
+func TestStepConditions(t *testing.T) {
+	step := gitlabci.MakeStep("sbom")
+
+	require.NoError(t, step.SetConditions("only-on-tag", ""))
+	step.SetConditionOnlyOnBranch("main")
+
+	assert.Equal(t, `if [ -n "${CI_COMMIT_TAG}" ] && [ "${CI_COMMIT_BRANCH}" = "main" ]; then make sbom; fi`, step.String())
+
+	assert.EqualError(t, step.SetConditions("on-release"), "unsupported condition for GitLab CI: on-release")
+}
+
+func TestJobConditions(t *testing.T) {
+	var job gitlabci.Job
+
+	require.NoError(t, job.SetConditions("only-on-tag", "always"))
+	assert.Equal(t, []gitlabci.Rule{{If: "$CI_COMMIT_TAG", When: "always"}}, job.Rules)
+
+	assert.EqualError(t, job.SetConditions("contains(github.event.labels, 'x')"), "unsupported condition for GitLab CI: contains(github.event.labels, 'x')")
+}
+
+func (suite *GitLabCISuite) TestTestReports() {
+	o := gitlabci.NewOutput("master")
+
+	o.AddStep(gitlabci.DefaultJobName, gitlabci.MakeStep("base"))
+	o.AddStepInParallelJob("unit-tests-shard", nil, gitlabci.MakeStep("unit-tests-shard-${CI_NODE_INDEX}"))
+	o.AddArtifacts("unit-tests-shard", "", "_out/coverage-unit-tests-shard-*.txt")
+	o.SetParallel("unit-tests-shard", 2)
+	o.AddTestReports("unit-tests-shard", "_out/junit-unit-tests-shard-*.xml")
+	o.AddStepInParallelJob("unit-tests", nil, gitlabci.Command("cat _out/coverage-unit-tests-shard-*.txt"))
+	o.AddJobNeeds("unit-tests", "unit-tests-shard", "default")
+
+	benchStep := gitlabci.MakeStep("bench")
+	suite.Require().NoError(benchStep.SetConditions("only-on-main-branch"))
+
+	bench := &gitlabci.Job{Stage: gitlabci.StageTest}
+	suite.Require().NoError(bench.SetConditions("only-on-main-branch"))
+
+	o.AddJob("bench", bench)
+	o.AddStep("bench", benchStep)
+
+	assertGolden(suite.T(), suite.generate(o))
+}
+
+type (
+	githubNode struct{}
+	gitlabNode struct{ githubNode }
+)
+
+func (githubNode) Name() string                                   { return "helm" }
+func (githubNode) CompileGitHubWorkflow(*ghworkflow.Output) error { return nil }
+func (gitlabNode) CompileGitLabCI(*gitlabci.Output) error         { return nil }
+
+func TestGuard(t *testing.T) {
+	guard := gitlabci.Guard()
+
+	require.NoError(t, guard.Compile(struct{}{}))
+	require.NoError(t, guard.Compile(gitlabNode{}))
+	assert.EqualError(t, guard.Compile(githubNode{}), "helm is not supported with GitLab CI")
+}
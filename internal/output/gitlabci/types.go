@@ -0,0 +1,181 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gitlabci
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Pipeline represents GitLab CI pipeline configuration.
+//
+//nolint:govet
+type Pipeline struct {
+	Workflow  *Workflow         `yaml:"workflow,omitempty"`
+	Stages    []string          `yaml:"stages"`
+	Default   *Default          `yaml:"default,omitempty"`
+	Variables map[string]string `yaml:"variables,omitempty"`
+
+	Jobs map[string]*Job `yaml:",inline"`
+}
+
+// Workflow represents GitLab CI workflow rules controlling when pipelines are created.
+type Workflow struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Default represents GitLab CI defaults inherited by every job.
+type Default struct {
+	Image        string   `yaml:"image,omitempty"`
+	Services     []string `yaml:"services,omitempty"`
+	BeforeScript []string `yaml:"before_script,omitempty"`
+	Tags         []string `yaml:"tags,omitempty"`
+}
+
+// Job represents GitLab CI job.
+//
+//nolint:govet
+type Job struct {
+	Stage     string             `yaml:"stage"`
+	Image     string             `yaml:"image,omitempty"`
+	Inherit   *Inherit           `yaml:"inherit,omitempty"`
+	Needs     []Need             `yaml:"needs,omitempty"`
+	Rules     []Rule             `yaml:"rules,omitempty"`
+	Variables map[string]string  `yaml:"variables,omitempty"`
+	IDTokens  map[string]IDToken `yaml:"id_tokens,omitempty"`
+	Tags      []string           `yaml:"tags,omitempty"`
+	Parallel  int                `yaml:"parallel,omitempty"`
+	Script    []string           `yaml:"script"`
+	Artifacts *Artifacts         `yaml:"artifacts,omitempty"`
+	Release   *Release           `yaml:"release,omitempty"`
+}
+
+// IDToken represents OIDC ID token exposed to the job as a variable.
+type IDToken struct {
+	Aud string `yaml:"aud"`
+}
+
+// Inherit controls which global defaults the job inherits.
+type Inherit struct {
+	Default bool `yaml:"default"`
+}
+
+// Need represents a dependency of the job on another job.
+type Need struct {
+	Job          string
+	SkipArtifact bool
+}
+
+// MarshalYAML implements yaml.Marshaler.
+func (need Need) MarshalYAML() (any, error) {
+	if !need.SkipArtifact {
+		return need.Job, nil
+	}
+
+	return map[string]any{
+		"job":       need.Job,
+		"artifacts": false,
+	}, nil
+}
+
+// Rule represents GitLab CI rule.
+type Rule struct {
+	If      string   `yaml:"if,omitempty"`
+	Changes []string `yaml:"changes,omitempty"`
+	When    string   `yaml:"when,omitempty"`
+}
+
+// Artifacts represents GitLab CI job artifacts.
+type Artifacts struct {
+	Name     string            `yaml:"name,omitempty"`
+	When     string            `yaml:"when,omitempty"`
+	ExpireIn string            `yaml:"expire_in,omitempty"`
+	Paths    []string          `yaml:"paths,omitempty"`
+	Reports  *ArtifactsReports `yaml:"reports,omitempty"`
+}
+
+// ArtifactsReports represents GitLab CI job reports shown in the merge requests.
+type ArtifactsReports struct {
+	JUnit []string `yaml:"junit,omitempty"`
+}
+
+// Release represents GitLab CI release created by the job.
+type Release struct {
+	TagName     string         `yaml:"tag_name"`
+	Name        string         `yaml:"name"`
+	Description string         `yaml:"description"`
+	Assets      *ReleaseAssets `yaml:"assets,omitempty"`
+}
+
+// ReleaseAssets represents GitLab release assets.
+type ReleaseAssets struct {
+	Links []ReleaseLink `yaml:"links"`
+}
+
+// ReleaseLink represents a single link in GitLab release assets.
+type ReleaseLink struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// SetConditions sets job conditions.
+//
+// Conditions use the same keywords as GitHub Actions steps, e.g. "on-pull-request" or "only-on-tag".
+func (job *Job) SetConditions(conditions ...string) error {
+	var (
+		expressions []string
+		when        string
+	)
+
+	for _, condition := range conditions {
+		switch condition {
+		case "always":
+			when = "always"
+		case "":
+		default:
+			expression, ok := ruleConditions[condition]
+			if !ok {
+				return fmt.Errorf("unsupported condition for GitLab CI: %s", condition)
+			}
+
+			expressions = append(expressions, expression)
+		}
+	}
+
+	if len(expressions) == 0 && when == "" {
+		return nil
+	}
+
+	job.Rules = append(job.Rules, Rule{
+		If:   strings.Join(expressions, " && "),
+		When: when,
+	})
+
+	return nil
+}
+
+// ruleConditions maps condition keywords to GitLab CI rule expressions.
+var ruleConditions = map[string]string{
+	"except-pull-request": `$CI_PIPELINE_SOURCE != "merge_request_event"`,
+	"on-pull-request":     `$CI_PIPELINE_SOURCE == "merge_request_event"`,
+	"only-on-tag":         `$CI_COMMIT_TAG`,
+	"only-on-stable-tag":  `$CI_COMMIT_TAG && $CI_COMMIT_TAG !~ /-/`,
+	"not-on-tag":          `$CI_COMMIT_TAG == null`,
+	"only-on-schedule":    `$CI_PIPELINE_SOURCE == "schedule"`,
+	"not-on-schedule":     `$CI_PIPELINE_SOURCE != "schedule"`,
+	"only-on-main-branch": `$CI_COMMIT_BRANCH == $` + MainBranchVariable,
+}
+
+// shellConditions maps condition keywords to shell tests on GitLab CI predefined variables.
+var shellConditions = map[string]string{
+	"except-pull-request": `[ "${CI_PIPELINE_SOURCE}" != "merge_request_event" ]`,
+	"on-pull-request":     `[ "${CI_PIPELINE_SOURCE}" = "merge_request_event" ]`,
+	"only-on-tag":         `[ -n "${CI_COMMIT_TAG}" ]`,
+	"only-on-stable-tag":  `[ -n "${CI_COMMIT_TAG}" ] && [ "${CI_COMMIT_TAG#*-}" = "${CI_COMMIT_TAG}" ]`,
+	"not-on-tag":          `[ -z "${CI_COMMIT_TAG}" ]`,
+	"only-on-schedule":    `[ "${CI_PIPELINE_SOURCE}" = "schedule" ]`,
+	"not-on-schedule":     `[ "${CI_PIPELINE_SOURCE}" != "schedule" ]`,
+	"only-on-main-branch": `[ "${CI_COMMIT_BRANCH}" = "${` + MainBranchVariable + `}" ]`,
+}
@@ -6,14 +6,16 @@
 package output
 
 // Writer is an interface which should be implemented by outputs.
+//
+// Generate writes the output files to the passed filesystem.
 type Writer interface {
-	Generate() error
+	Generate(FS) error
 	Compile(any) error
 }
 
 // TypedWriter is an interface which should be implemented by outputs. It is a typed version of Writer.
 type TypedWriter[T any] interface {
-	Generate() error
+	Generate(FS) error
 	Compile(T) error
 }
 
@@ -21,7 +23,7 @@ type adapter[T any] struct {
 	inner TypedWriter[T]
 }
 
-func (w *adapter[T]) Generate() error { return w.inner.Generate() }
+func (w *adapter[T]) Generate(fsys FS) error { return w.inner.Generate(fsys) }
 
 func (w *adapter[T]) Compile(i any) error {
 	val, ok := i.(T)
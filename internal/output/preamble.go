@@ -64,6 +64,11 @@ Generated on %s by %s.
 	return strings.Join(byLines, "\n") + "\n\n"
 }
 
+// MPLHeader is the Mozilla Public License 2.0 header.
+const MPLHeader = `This Source Code Form is subject to the terms of the Mozilla Public
+License, v. 2.0. If a copy of the MPL was not distributed with this
+file, You can obtain one at http://mozilla.org/MPL/2.0/.`
+
 // License returns file auto-generated license.
 func License(license, commentPrefix string) string {
 	byLines := strings.Split(strings.TrimSpace(license), "\n")
@@ -66,7 +66,7 @@ func (o *Output) GenerateFile(filename string, w io.Writer) error {
 	case releaseScript:
 		return o.releaseScript(w)
 	case releaseTemplate:
-		return o.releaseTemplate(filename, w)
+		return o.releaseTemplate(w)
 	default:
 		panic("unexpected filename: " + filename)
 	}
@@ -97,16 +97,12 @@ func (o *Output) releaseScript(w io.Writer) error {
 	return nil
 }
 
-func (o *Output) releaseTemplate(filename string, w io.Writer) error {
-	_, err := os.Stat(filename)
-	if err == nil {
-		return output.ErrSkip
-	}
-
-	if !errors.Is(err, os.ErrNotExist) {
-		return err
-	}
+// NoOverwrite implements output.FileNoOverwriteWriter interface.
+func (o *Output) NoOverwrite(filename string) bool {
+	return filename == releaseTemplate
+}
 
+func (o *Output) releaseTemplate(w io.Writer) error {
 	// no preamble as this file is meant to be edited
 
 	if o.meta == nil {
@@ -8,12 +8,10 @@ package template
 import (
 	"fmt"
 	"io"
-	"os"
 	"slices"
 	"text/template"
 
 	"github.com/siderolabs/kres/internal/output"
-	"github.com/siderolabs/kres/internal/project/common"
 )
 
 // FileTemplate defines a single file template to be generated by this output.
@@ -73,21 +71,10 @@ func (t *FileTemplate) Params(value any) *FileTemplate {
 }
 
 func (t *FileTemplate) write(w io.Writer) error {
-	if t.noOverwrite {
-		_, err := os.Stat(t.name)
-		if err != nil {
-			if !os.IsNotExist(err) {
-				return err
-			}
-		} else {
-			return output.ErrSkip
-		}
-	}
-
 	if t.withLicense {
 		licenseText := t.withLicenseText
 		if licenseText == "" {
-			licenseText = common.MPLHeader
+			licenseText = output.MPLHeader
 		}
 
 		if _, err := w.Write([]byte(output.License(licenseText, t.preamblePrefix) + "\n")); err != nil {
@@ -183,6 +170,13 @@ func (o *Output) ManagedFilenames() []string {
 	return files
 }
 
+// NoOverwrite implements output.FileNoOverwriteWriter interface.
+func (o *Output) NoOverwrite(filename string) bool {
+	t, ok := o.templates[filename]
+
+	return ok && t.noOverwrite
+}
+
 // GenerateFile implements output.FileWriter interface.
 func (o *Output) GenerateFile(filename string, w io.Writer) error {
 	if t, ok := o.templates[filename]; ok {
@@ -10,7 +10,9 @@ import (
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/project"
 	"github.com/siderolabs/kres/internal/project/common"
+	"github.com/siderolabs/kres/internal/project/custom"
 	"github.com/siderolabs/kres/internal/project/meta"
+	"github.com/siderolabs/kres/internal/project/service"
 )
 
 // builder keeps state of project contents being built.
@@ -25,7 +27,11 @@ type builder struct {
 	lintInputs []dag.Node
 	lintTarget *common.Lint
 
+	coverage *service.CodeCov
+
 	targets []dag.Node
+
+	customSteps []*custom.Step
 }
 
 type (
@@ -141,11 +147,12 @@ func (builder *builder) build() error {
 	sops := common.NewSOPS(builder.meta)
 	renovate := common.NewRenovate(builder.meta)
 	gitattributes := common.NewGitattributes(builder.meta)
+	templates := common.NewTemplates(builder.meta)
 
 	release.AddInput(builder.targets...)
 
 	builder.proj.AddTarget(builder.targets...)
-	builder.proj.AddTarget(rekres, all, makeHelp, release, conformance, sops, renovate, gitattributes)
+	builder.proj.AddTarget(rekres, all, makeHelp, release, conformance, sops, renovate, gitattributes, templates)
 
-	return nil
+	return builder.applyOverrides()
 }
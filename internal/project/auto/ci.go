@@ -5,8 +5,11 @@
 package auto
 
 import (
+	"fmt"
+
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/project/common"
+	"github.com/siderolabs/kres/internal/project/meta"
 )
 
 // DetectCI checks the ci settings.
@@ -17,6 +20,23 @@ func (builder *builder) DetectCI() (bool, error) {
 		return false, err
 	}
 
+	switch ci.Provider {
+	case "", meta.CIProviderGitHub:
+		builder.meta.CIProvider = meta.CIProviderGitHub
+	case meta.CIProviderGitLab:
+		if ci.CompileGHWorkflowsOnly {
+			return false, fmt.Errorf("ci.compileGHWorkflowsOnly can't be used with the %q provider", ci.Provider)
+		}
+
+		builder.meta.CIProvider = meta.CIProviderGitLab
+	case meta.CIProviderForgejo:
+		builder.meta.CIProvider = meta.CIProviderForgejo
+		builder.meta.ForgejoActionsURL = ci.Forgejo.ActionsURL
+		builder.meta.ForgejoRunners = ci.Forgejo.Runners
+	default:
+		return false, fmt.Errorf("unsupported ci.provider %q, supported providers: %s, %s, %s", ci.Provider, meta.CIProviderGitHub, meta.CIProviderGitLab, meta.CIProviderForgejo)
+	}
+
 	builder.meta.CompileGithubWorkflowsOnly = ci.CompileGHWorkflowsOnly
 
 	return true, nil
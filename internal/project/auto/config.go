@@ -36,6 +36,14 @@ type CustomStep struct {
 
 // CI defines CI settings.
 type CI struct {
+	// Forgejo defines settings of the forgejo provider.
+	Forgejo struct {
+		// Runners maps runner groups to Forgejo runner labels, unmapped groups run on "docker".
+		Runners map[string]string `yaml:"runners"`
+		// ActionsURL is the base URL of the mirror to resolve the actions from.
+		ActionsURL string `yaml:"actionsURL"`
+	} `yaml:"forgejo"`
+	// Provider is the CI system to generate the configuration for: github (default), gitlab or forgejo.
 	Provider string `yaml:"provider"`
 	// CompileGHWorkflowsOnly is a flag to generate only GitHub Actions.
 	CompileGHWorkflowsOnly bool `yaml:"compileGHWorkflowsOnly"`
@@ -50,6 +58,7 @@ type Helm struct {
 	Enabled           bool         `yaml:"enabled"`
 	DocsDisabled      bool         `yaml:"docsDisabled"`
 	SchemaDisabled    bool         `yaml:"schemaDisabled"`
+	JUnit             bool         `yaml:"junit"`
 }
 
 // HelmTemplate defines helm template settings.
@@ -69,9 +78,13 @@ type IntegrationTests struct {
 
 // IntegrationTestConfig defines the integration tests build configuration.
 type IntegrationTestConfig struct {
-	Outputs           map[string]map[string]string `yaml:"outputs"`
-	Name              string                       `yaml:"name"`
-	Path              string                       `yaml:"path"`
-	ImageName         string                       `yaml:"imageName"`
-	EnableDockerImage bool                         `yaml:"enableDockerImage"`
+	Outputs map[string]map[string]string `yaml:"outputs"`
+	Name    string                       `yaml:"name"`
+	Path    string                       `yaml:"path"`
+	// Tags are the build tags of the test binary, defaults to `integration` and `sidero.debug`.
+	Tags              []string `yaml:"tags"`
+	ImageName         string   `yaml:"imageName"`
+	EnableDockerImage bool     `yaml:"enableDockerImage"`
+	// Run generates the `golang.IntegrationTests` node named `<name>-run` which runs the test binary.
+	Run bool `yaml:"run"`
 }
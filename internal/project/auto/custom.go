@@ -60,6 +60,7 @@ func (builder *builder) BuildCustom() error {
 		}
 
 		createdSteps = append(createdSteps, step)
+		builder.customSteps = append(builder.customSteps, step)
 	}
 
 	return nil
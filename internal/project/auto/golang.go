@@ -9,9 +9,12 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"slices"
+	"strings"
 
+	"github.com/siderolabs/gen/xslices"
 	"golang.org/x/mod/modfile"
 
 	"github.com/siderolabs/kres/internal/config"
@@ -29,6 +32,16 @@ func (builder *builder) DetectGolang() (bool, error) {
 		return false, nil
 	}
 
+	if err := builder.detectGoWorkspace(); err != nil {
+		return false, err
+	}
+
+	var sources golang.Sources
+
+	if err := builder.meta.Config.Load(&sources); err != nil {
+		return false, err
+	}
+
 	var lookupDirs []string
 
 	err := filepath.Walk(builder.rootPath, func(path string, info os.FileInfo, err error) error {
@@ -60,7 +73,11 @@ func (builder *builder) DetectGolang() (bool, error) {
 			return false, err
 		}
 
-		if err := builder.processDirectory(dir); err != nil {
+		if len(builder.meta.GoWorkspaceModules) > 0 && !slices.Contains(builder.meta.GoWorkspaceModules, dir) {
+			return false, fmt.Errorf("module %q is not used in go.work, add it to go.work or ignore it with .kresignore", dir)
+		}
+
+		if err := builder.processDirectory(dir, &sources); err != nil {
 			return false, err
 		}
 
@@ -71,11 +88,60 @@ func (builder *builder) DetectGolang() (bool, error) {
 		return false, errors.New("no Go source files found")
 	}
 
+	if err := builder.processCommands(&sources); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
 
+// detectGoWorkspace detects go.work at the root of the project.
+func (builder *builder) detectGoWorkspace() error {
+	workPath := filepath.Join(builder.rootPath, "go.work")
+
+	contents, err := os.ReadFile(workPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+
+		return err
+	}
+
+	work, err := modfile.ParseWork(workPath, contents, nil)
+	if err != nil {
+		return err
+	}
+
+	for _, use := range work.Use {
+		dir := filepath.Clean(filepath.FromSlash(use.Path))
+
+		if filepath.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s: module %q is outside of the project", workPath, use.Path)
+		}
+
+		builder.meta.GoWorkspaceModules = append(builder.meta.GoWorkspaceModules, filepath.Join(builder.rootPath, dir))
+	}
+
+	if err = builder.processReplaces(builder.rootPath, workPath, work.Replace); err != nil {
+		return err
+	}
+
+	builder.meta.GoWorkspaceFiles = append(builder.meta.GoWorkspaceFiles, workPath)
+
+	if _, err = os.Stat(workPath + ".sum"); err == nil {
+		builder.meta.GoWorkspaceFiles = append(builder.meta.GoWorkspaceFiles, workPath+".sum")
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	builder.meta.SourceFiles = append(builder.meta.SourceFiles, builder.meta.GoWorkspaceFiles...)
+
+	return nil
+}
+
 //nolint:gocognit,gocyclo,cyclop
-func (builder *builder) processDirectory(path string) error {
+func (builder *builder) processDirectory(path string, sources *golang.Sources) error {
 	var (
 		canonicalPath string
 		dir           = filepath.Join(builder.rootPath, path)
@@ -101,10 +167,21 @@ func (builder *builder) processDirectory(path string) error {
 		}
 
 		canonicalPath = modfile.ModulePath(contents)
+
+		gomodFile, err := modfile.Parse(gomodPath, contents, nil)
+		if err != nil {
+			return err
+		}
+
+		if err = builder.processReplaces(dir, gomodPath, gomodFile.Replace); err != nil {
+			return err
+		}
 	}
 
 	builder.meta.CanonicalPaths = append(builder.meta.CanonicalPaths, canonicalPath)
 
+	var srcDirs []string
+
 	for _, srcDir := range []string{
 		"api",         // API definitions (generated protobufs, Kubebuilder's resources)
 		"cmd",         // main packages
@@ -119,13 +196,12 @@ func (builder *builder) processDirectory(path string) error {
 		}
 
 		if exists {
-			builder.meta.Directories = append(builder.meta.Directories, filepath.Join(dir, srcDir))
-			builder.meta.GoDirectories = append(builder.meta.GoDirectories, filepath.Join(dir, srcDir))
+			srcDirs = append(srcDirs, srcDir)
 		}
 	}
 
 	{
-		// assume any directory with `.go` files is a source directory
+		// assume any directory with Go files is a source directory
 		topLevel, err := os.ReadDir(dir)
 		if err != nil {
 			return err
@@ -136,24 +212,65 @@ func (builder *builder) processDirectory(path string) error {
 				continue
 			}
 
-			if slices.Index(builder.meta.Directories, filepath.Join(dir, item.Name())) != -1 {
+			if slices.Contains(srcDirs, item.Name()) || slices.Contains(builder.meta.Directories, filepath.Join(dir, item.Name())) {
 				continue
 			}
 
-			result, err := listFilesWithSuffix(filepath.Join(dir, item.Name()), ".go")
+			result, err := listGoSourceFiles(filepath.Join(dir, item.Name()))
 			if err != nil {
 				return err
 			}
 
 			if len(result) > 0 {
-				builder.meta.Directories = append(builder.meta.Directories, filepath.Join(dir, item.Name()))
-				builder.meta.GoDirectories = append(builder.meta.GoDirectories, filepath.Join(dir, item.Name()))
+				srcDirs = append(srcDirs, item.Name())
 			}
 		}
 	}
 
+	for _, pattern := range sources.Include {
+		matches, err := filepath.Glob(filepath.Join(dir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return fmt.Errorf("golang.Sources: invalid include pattern %q: %w", pattern, err)
+		}
+
+		for _, match := range matches {
+			st, err := os.Stat(match)
+			if err != nil {
+				return err
+			}
+
+			srcDir, err := filepath.Rel(dir, match)
+			if err != nil {
+				return err
+			}
+
+			if st.IsDir() && !slices.Contains(srcDirs, srcDir) {
+				srcDirs = append(srcDirs, srcDir)
+			}
+		}
+	}
+
+	for _, srcDir := range srcDirs {
+		excluded, err := sourcesExcluded(sources, srcDir)
+		if err != nil {
+			return err
+		}
+
+		if excluded {
+			continue
+		}
+
+		if !slices.Contains(builder.meta.Directories, filepath.Join(dir, srcDir)) {
+			builder.meta.Directories = append(builder.meta.Directories, filepath.Join(dir, srcDir))
+		}
+
+		if !slices.Contains(builder.meta.GoDirectories, filepath.Join(dir, srcDir)) {
+			builder.meta.GoDirectories = append(builder.meta.GoDirectories, filepath.Join(dir, srcDir))
+		}
+	}
+
 	{
-		list, err := listFilesWithSuffix(dir, ".go")
+		list, err := listGoSourceFiles(dir)
 		if err != nil {
 			return err
 		}
@@ -199,7 +316,8 @@ func (builder *builder) processDirectory(path string) error {
 		return err
 	}
 
-	if cmdExists {
+	// explicitly configured commands replace the discovered ones
+	if cmdExists && len(sources.Commands) == 0 {
 		path := filepath.Join(dir, "cmd")
 
 		dirs, err := os.ReadDir(path)
@@ -207,11 +325,20 @@ func (builder *builder) processDirectory(path string) error {
 			return err
 		}
 
-		for _, dir := range dirs {
-			if dir.IsDir() {
+		for _, cmdDir := range dirs {
+			if !cmdDir.IsDir() {
+				continue
+			}
+
+			excluded, err := sourcesExcluded(sources, filepath.Join("cmd", cmdDir.Name()))
+			if err != nil {
+				return err
+			}
+
+			if !excluded {
 				builder.meta.Commands = append(builder.meta.Commands, meta.Command{
-					Path: filepath.Join(path, dir.Name()),
-					Name: dir.Name(),
+					Path: filepath.Join(path, cmdDir.Name()),
+					Name: cmdDir.Name(),
 				})
 			}
 		}
@@ -220,6 +347,100 @@ func (builder *builder) processDirectory(path string) error {
 	return nil
 }
 
+// sourcesExcluded checks whether the directory relative to the Go module root or any of its parents is excluded by golang.Sources.
+func sourcesExcluded(sources *golang.Sources, srcDir string) (bool, error) {
+	for dir := filepath.ToSlash(srcDir); dir != "."; dir = path.Dir(dir) {
+		for _, pattern := range sources.Exclude {
+			matched, err := path.Match(pattern, dir)
+			if err != nil {
+				return false, fmt.Errorf("golang.Sources: invalid exclude pattern %q: %w", pattern, err)
+			}
+
+			if matched {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// processCommands adds the commands configured explicitly in golang.Sources.
+func (builder *builder) processCommands(sources *golang.Sources) error {
+	for _, cmd := range sources.Commands {
+		if cmd.Name == "" || cmd.Path == "" {
+			return errors.New("golang.Sources: command name and path are required")
+		}
+
+		if slices.ContainsFunc(builder.meta.Commands, func(c meta.Command) bool { return c.Name == cmd.Name }) {
+			return fmt.Errorf("golang.Sources: command %q is defined more than once", cmd.Name)
+		}
+
+		cmdPath := filepath.Clean(filepath.FromSlash(cmd.Path))
+
+		if filepath.IsAbs(cmdPath) || cmdPath == ".." || strings.HasPrefix(cmdPath, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("golang.Sources: command %q path %q is outside of the project", cmd.Name, cmd.Path)
+		}
+
+		exists, err := directoryExists(builder.rootPath, cmdPath)
+		if err != nil {
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("golang.Sources: command %q path %q is not a directory", cmd.Name, cmd.Path)
+		}
+
+		cmdPath = filepath.Join(builder.rootPath, cmdPath)
+
+		builder.meta.Commands = append(builder.meta.Commands, meta.Command{
+			Path: cmdPath,
+			Name: cmd.Name,
+		})
+
+		// commands outside of the source directories bring their package into the build context,
+		// commands at the root of the module are built from the top-level Go files
+		if slices.Contains(builder.meta.GoRootDirectories, cmdPath) || slices.ContainsFunc(builder.meta.GoDirectories, func(dir string) bool {
+			return cmdPath == dir || strings.HasPrefix(cmdPath, dir+string(filepath.Separator))
+		}) {
+			continue
+		}
+
+		builder.meta.Directories = append(builder.meta.Directories, cmdPath)
+		builder.meta.GoDirectories = append(builder.meta.GoDirectories, cmdPath)
+	}
+
+	return nil
+}
+
+// processReplaces records the local directories referenced by replace directives of go.mod or go.work in dir.
+//
+// The directories are copied into the base stage before the modules are downloaded.
+func (builder *builder) processReplaces(dir, modPath string, replaces []*modfile.Replace) error {
+	for _, replace := range replaces {
+		if !modfile.IsDirectoryPath(replace.New.Path) {
+			continue
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(replace.New.Path))
+
+		rel, err := filepath.Rel(builder.rootPath, target)
+		if err != nil || filepath.IsAbs(replace.New.Path) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("%s: replace %s => %s points outside of the project", modPath, replace.Old.Path, replace.New.Path)
+		}
+
+		if !slices.Contains(builder.meta.GoReplaceDirectories, target) {
+			builder.meta.GoReplaceDirectories = append(builder.meta.GoReplaceDirectories, target)
+		}
+
+		if !slices.Contains(builder.meta.Directories, target) {
+			builder.meta.Directories = append(builder.meta.Directories, target)
+		}
+	}
+
+	return nil
+}
+
 // BuildGolang builds project structure for Go project.
 func (builder *builder) BuildGolang() error {
 	// toolchain as the root of the tree
@@ -258,21 +479,42 @@ func (builder *builder) BuildGolang() error {
 	builder.lintInputs = append(builder.lintInputs, toolchain, linters)
 
 	coverage := service.NewCodeCov(builder.meta)
+	builder.coverage = coverage
 	allUnitTests := make([]dag.Node, 0, len(builder.meta.CanonicalPaths))
 
+	// Go workspace is linted with golangci-lint and tested as a whole from the workspace root
+	workspace := len(builder.meta.GoWorkspaceModules) > 0
+
 	// linters
-	for _, projectPath := range builder.meta.GoRootDirectories {
-		golangciLint := golang.NewGolangciLint(builder.meta, projectPath)
-		gofumpt := golang.NewGofumpt(builder.meta, projectPath)
-		govulncheck := golang.NewGoVulnCheck(builder.meta, builder.rootPath, projectPath)
+	for i, projectPath := range builder.meta.GoRootDirectories {
+		wholeProject, wholeProjectPath := !workspace || i == 0, projectPath
+		if workspace {
+			wholeProjectPath = "."
+		}
+
+		var linters []dag.Node
+
+		if wholeProject {
+			linters = append(linters, golang.NewGolangciLint(builder.meta, wholeProjectPath))
+		}
+
+		linters = append(linters,
+			golang.NewGofumpt(builder.meta, projectPath),
+			golang.NewGoVulnCheck(builder.meta, builder.rootPath, projectPath),
+		)
 
 		// linters are input to the toolchain as they inject into toolchain build
-		toolchain.AddInput(golangciLint, gofumpt, govulncheck)
+		toolchain.AddInput(linters...)
+
+		builder.lintInputs = append(builder.lintInputs, toolchain)
+		builder.lintInputs = append(builder.lintInputs, linters...)
 
-		builder.lintInputs = append(builder.lintInputs, toolchain, golangciLint, gofumpt, govulncheck)
+		if !wholeProject {
+			continue
+		}
 
 		// unit-tests
-		unitTests := golang.NewUnitTests(builder.meta, projectPath)
+		unitTests := golang.NewUnitTests(builder.meta, wholeProjectPath)
 		unitTests.AddInput(toolchain)
 
 		coverage.AddInput(unitTests)
@@ -280,7 +522,34 @@ func (builder *builder) BuildGolang() error {
 		builder.targets = append(builder.targets, unitTests)
 		allUnitTests = append(allUnitTests, unitTests)
 
-		coverage.AddDiscoveredInputs("unit-tests", "unit-tests", fmt.Sprintf("coverage-%s.txt", unitTests.Name()))
+		// fuzz tests and benchmarks are long-running, so they're not a part of `all` or the release
+		fuzzTests, err := builder.discoverTestFunctions(wholeProjectPath, "Fuzz")
+		if err != nil {
+			return err
+		}
+
+		fuzz := golang.NewFuzz(builder.meta, wholeProjectPath, xslices.Map(fuzzTests, func(fn testFunction) golang.FuzzTarget {
+			return golang.FuzzTarget{Package: fn.pkg, Name: fn.name}
+		}))
+
+		// the fuzz targets might be configured explicitly
+		if len(fuzzTests) > 0 || builder.meta.Config.Defines(fuzz) {
+			fuzz.AddInput(toolchain)
+
+			builder.proj.AddTarget(fuzz)
+		}
+
+		benchmarks, err := builder.discoverTestFunctions(wholeProjectPath, "Benchmark")
+		if err != nil {
+			return err
+		}
+
+		if len(benchmarks) > 0 {
+			bench := golang.NewBenchmarks(builder.meta, wholeProjectPath)
+			bench.AddInput(toolchain)
+
+			builder.proj.AddTarget(bench)
+		}
 	}
 
 	builder.targets = append(builder.targets, coverage)
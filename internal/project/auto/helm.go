@@ -70,6 +70,7 @@ func (builder *builder) DetectHelm() (bool, error) {
 	builder.meta.HelmTemplateFlags = flags
 	builder.meta.EnforceHelmDocs = !helm.DocsDisabled
 	builder.meta.EnforceHelmSchema = !helm.SchemaDisabled
+	builder.meta.HelmJUnit = helm.JUnit
 	builder.meta.ChartVersionMajor = helm.ChartVersionMajor
 
 	return true, nil
@@ -53,10 +53,27 @@ func (builder *builder) BuildIntegrationTests() error {
 			return k, golang.CompileConfig(m)
 		})
 
-		build.BuildFlags = append(build.BuildFlags, "-tags integration,sidero.debug")
+		tags := spec.Tags
+		if len(tags) == 0 {
+			tags = []string{"integration", "sidero.debug"}
+		}
+
+		build.BuildFlags = append(build.BuildFlags, "-tags "+strings.Join(tags, ","))
 
 		builder.targets = append(builder.targets, build)
 
+		// the tests are run against the service containers, so they're not a part of `all` or the release
+		if spec.Run {
+			run := golang.NewIntegrationTests(builder.meta, spec.Name)
+			run.AddInput(build)
+
+			if builder.coverage != nil {
+				builder.coverage.AddInput(run)
+			}
+
+			builder.proj.AddTarget(run)
+		}
+
 		if spec.EnableDockerImage {
 			imageName := spec.Name
 
@@ -0,0 +1,80 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/project/common"
+	"github.com/siderolabs/kres/internal/project/custom"
+	"github.com/siderolabs/kres/internal/project/golang"
+	"github.com/siderolabs/kres/internal/project/helm"
+	"github.com/siderolabs/kres/internal/project/js"
+	"github.com/siderolabs/kres/internal/project/markdown"
+	"github.com/siderolabs/kres/internal/project/pkgfile"
+	"github.com/siderolabs/kres/internal/project/service"
+)
+
+// ConfigKinds returns an empty object for every type which might be configured via `.kres.yaml`.
+//
+// New node types and builder settings should be registered here to be covered by `kres schema`.
+func ConfigKinds() []any {
+	return []any{
+		// config settings
+		&config.Include{},
+		&config.Vars{},
+
+		// builder settings
+		&CI{},
+		&CommandConfig{},
+		&CustomSteps{},
+		&Helm{},
+		&IntegrationTests{},
+		&Overrides{},
+
+		// project nodes
+		&common.All{},
+		&common.Build{},
+		&common.CheckDirty{},
+		&common.Conformance{},
+		&common.Docker{},
+		&common.GHWorkflow{},
+		&common.Gitattributes{},
+		&common.Image{},
+		&common.Lint{},
+		&common.MakeHelp{},
+		&common.ReKres{},
+		&common.Release{},
+		&common.Renovate{},
+		&common.Repository{},
+		&common.SBOM{},
+		&common.SOPS{},
+		&common.SourceAssets{},
+		&common.Templates{},
+		&custom.Step{},
+		&golang.Benchmarks{},
+		&golang.Build{},
+		&golang.DeepCopy{},
+		&golang.Fuzz{},
+		&golang.Generate{},
+		&golang.Gofumpt{},
+		&golang.GolangciLint{},
+		&golang.GoVulnCheck{},
+		&golang.IntegrationTests{},
+		&golang.Linters{},
+		&golang.Sources{},
+		&golang.Toolchain{},
+		&golang.UnitTests{},
+		&helm.Build{},
+		&js.Build{},
+		&js.Chromatic{},
+		&js.EsLint{},
+		&js.Protobuf{},
+		&js.Toolchain{},
+		&js.UnitTests{},
+		&markdown.Lint{},
+		&pkgfile.Build{},
+		&service.CodeCov{},
+	}
+}
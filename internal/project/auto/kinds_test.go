@@ -0,0 +1,168 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/dag"
+	"github.com/siderolabs/kres/internal/project/auto"
+	"github.com/siderolabs/kres/internal/project/meta"
+)
+
+func TestConfigKindsUnique(t *testing.T) {
+	seen := map[string]struct{}{}
+
+	for _, obj := range auto.ConfigKinds() {
+		kind := config.Kind(reflect.TypeOf(obj))
+
+		assert.NotContains(t, seen, kind)
+
+		seen[kind] = struct{}{}
+	}
+}
+
+// TestConfigKindsComplete builds the projects covering all node types and checks that every node kind is registered.
+func TestConfigKindsComplete(t *testing.T) {
+	registered := map[string]struct{}{}
+
+	for _, obj := range auto.ConfigKinds() {
+		registered[config.Kind(reflect.TypeOf(obj))] = struct{}{}
+	}
+
+	built := map[string]struct{}{}
+
+	for _, test := range []struct {
+		name  string
+		files map[string]string
+	}{
+		{
+			name: "golang",
+			files: map[string]string{
+				"go.mod":              "module github.com/example/example\n\ngo 1.26\n",
+				"cmd/example/main.go": "package main\n\nfunc main() {}\n",
+				"pkg/example/example_test.go": "package example\n\nimport \"testing\"\n\n" +
+					"func FuzzExample(f *testing.F) {}\n\nfunc BenchmarkExample(b *testing.B) {}\n",
+				"internal/integration/integration_test.go": "//go:build integration\n\npackage integration\n",
+				"deploy/helm/example/Chart.yaml":           "apiVersion: v2\nname: example\nversion: 0.1.0\n",
+				"docs/README.md":                           "# example\n",
+				"frontend/package.json":                    "{}\n",
+				".kres.yaml": `kind: auto.Helm
+spec:
+  enabled: true
+  chartDir: deploy/helm/example
+---
+kind: auto.IntegrationTests
+spec:
+  tests:
+    - name: integration-test
+      path: internal/integration
+      run: true
+---
+kind: auto.CustomSteps
+spec:
+  steps:
+    - name: custom
+      toplevel: true
+---
+kind: common.SourceAssets
+spec:
+  images:
+    - ref: ghcr.io/example/assets:v1.0.0
+      copies:
+        - source: /assets
+          destination: assets
+`,
+			},
+		},
+		{
+			name: "pkgfile",
+			files: map[string]string{
+				"Pkgfile": "format: v1alpha2\n",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			writeGitFixture(t, test.files)
+
+			options := &meta.Options{
+				GoContainerVersion:     fmt.Sprintf("%s-alpine", config.GoVersion),
+				ContainerImageFrontend: config.ContainerImageFrontendDockerfile,
+			}
+
+			var err error
+
+			options.Config, err = config.NewProvider(".kres.yaml")
+			require.NoError(t, err)
+
+			options.Config.SetMetadata(options)
+
+			proj, err := auto.Build(options)
+			require.NoError(t, err)
+
+			require.NoError(t, dag.Walk(proj, func(node dag.Node) error {
+				kind := config.Kind(reflect.TypeOf(node))
+
+				assert.Contains(t, registered, kind, "node kind %s is not registered in ConfigKinds", kind)
+
+				built[kind] = struct{}{}
+
+				return nil
+			}, nil, -1))
+		})
+	}
+
+	// the projects above should stay fully featured, so that the new node types are covered
+	for _, obj := range auto.ConfigKinds() {
+		if _, ok := obj.(dag.Node); !ok {
+			continue
+		}
+
+		kind := config.Kind(reflect.TypeOf(obj))
+
+		assert.Contains(t, built, kind, "node kind %s is not built by any test project", kind)
+	}
+}
+
+// writeGitFixture lays out the files in a temporary git repository and changes into it.
+func writeGitFixture(t *testing.T, files map[string]string) {
+	t.Helper()
+
+	root := t.TempDir()
+
+	for name, contents := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+
+	repo, err := git.PlainInit(root, false)
+	require.NoError(t, err)
+
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{
+		Name: git.DefaultRemoteName,
+		URLs: []string{"https://github.com/example/example.git"},
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.CreateBranch(&gitconfig.Branch{
+		Name:   "main",
+		Remote: git.DefaultRemoteName,
+		Merge:  "refs/heads/main",
+	}))
+
+	t.Chdir(root)
+}
@@ -0,0 +1,120 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/siderolabs/gen/xslices"
+
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/dag"
+	"github.com/siderolabs/kres/internal/project/custom"
+)
+
+// OverridesKind is the kind of the config document overriding the project nodes.
+const OverridesKind = "kres.Overrides"
+
+// Overrides drops or replaces the project nodes detected by kres.
+//
+// Nodes are referenced by name, or by kind and name (e.g. `common.Build/build`) if the name is ambiguous.
+// Config documents of the dropped or replaced nodes are reported as not matching any node.
+//
+// Example:
+//
+//	---
+//	kind: kres.Overrides
+//	spec:
+//	  disable:
+//	    - lint-gofumpt
+//	  replace:
+//	    - lint-golangci-lint
+type Overrides struct {
+	// Disable lists the nodes to be dropped from the project, the dependants of the node get its inputs instead.
+	Disable []string `yaml:"disable"`
+	// Replace lists the nodes to be replaced with the custom step of the same name (defined in auto.CustomSteps),
+	// the custom step takes over the inputs and the dependants of the node.
+	Replace []string `yaml:"replace"`
+}
+
+// ConfigKind implements the config kind override.
+func (*Overrides) ConfigKind() string {
+	return OverridesKind
+}
+
+// applyOverrides drops and replaces the project nodes as configured.
+func (builder *builder) applyOverrides() error {
+	var overrides Overrides
+
+	if err := builder.meta.Config.Load(&overrides); err != nil {
+		return err
+	}
+
+	for _, ref := range overrides.Disable {
+		node, err := builder.findNode(ref, func(dag.Node) bool { return true })
+		if err != nil {
+			return err
+		}
+
+		builder.proj.Drop(node)
+	}
+
+	for _, ref := range overrides.Replace {
+		node, err := builder.findNode(ref, dag.Not(dag.Implements[*custom.Step]()))
+		if err != nil {
+			return err
+		}
+
+		idx := -1
+
+		for i, step := range builder.customSteps {
+			if step.Name() == node.Name() {
+				idx = i
+			}
+		}
+
+		if idx == -1 {
+			return fmt.Errorf("%s: custom step %q replacing the node is not defined", OverridesKind, node.Name())
+		}
+
+		builder.proj.Replace(node, builder.customSteps[idx])
+	}
+
+	return nil
+}
+
+// findNode finds the node by `name` or `kind/name` reference.
+func (builder *builder) findNode(ref string, condition dag.NodeCondition) (dag.Node, error) {
+	kind, name, ok := strings.Cut(ref, "/")
+	if !ok {
+		kind, name = "", ref
+	}
+
+	var matches []dag.Node
+
+	if err := dag.Walk(builder.proj, func(node dag.Node) error {
+		if node.Name() == name && (kind == "" || config.Kind(reflect.TypeOf(node)) == kind) && condition(node) {
+			matches = append(matches, node)
+		}
+
+		return nil
+	}, nil, -1); err != nil {
+		return nil, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%s: node %q not found", OverridesKind, ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, fmt.Errorf("%s: node name %q is ambiguous, use one of: %s", OverridesKind, ref,
+			strings.Join(xslices.Map(matches, func(node dag.Node) string {
+				return config.Kind(reflect.TypeOf(node)) + "/" + node.Name()
+			}), ", "))
+	}
+}
@@ -0,0 +1,151 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package auto
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// testFunction is a test function in a package.
+type testFunction struct {
+	pkg  string
+	name string
+}
+
+// discoverTestFunctions finds the test functions with the prefix (e.g. `Fuzz` for `func FuzzXxx(f *testing.F)`)
+// in the Go source directories of the project at projectPath.
+func (builder *builder) discoverTestFunctions(projectPath, prefix string) ([]testFunction, error) {
+	workspace := len(builder.meta.GoWorkspaceModules) > 0
+
+	// module owning the directory, the most specific root wins
+	moduleOf := func(dir string) string {
+		owner := ""
+
+		for _, root := range builder.meta.GoRootDirectories {
+			if (root == "." || dir == root || strings.HasPrefix(dir, root+string(filepath.Separator))) && len(root) >= len(owner) {
+				owner = root
+			}
+		}
+
+		return owner
+	}
+
+	var functions []testFunction
+
+	addTargets := func(dir string) error {
+		files, err := listGoSourceFiles(dir)
+		if err != nil {
+			return err
+		}
+
+		for _, file := range files {
+			if !strings.HasSuffix(file, "_test.go") {
+				continue
+			}
+
+			names, err := testFunctions(filepath.Join(dir, file), prefix)
+			if err != nil {
+				return err
+			}
+
+			pkg, err := filepath.Rel(filepath.Join(builder.rootPath, projectPath), dir)
+			if err != nil {
+				return err
+			}
+
+			if pkg != "." {
+				pkg = "./" + filepath.ToSlash(pkg)
+			}
+
+			for _, name := range names {
+				functions = append(functions, testFunction{
+					pkg:  pkg,
+					name: name,
+				})
+			}
+		}
+
+		return nil
+	}
+
+	if err := addTargets(filepath.Join(builder.rootPath, projectPath)); err != nil {
+		return nil, err
+	}
+
+	for _, srcDir := range builder.meta.GoDirectories {
+		if !workspace && moduleOf(srcDir) != projectPath {
+			continue
+		}
+
+		err := filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() {
+				return nil
+			}
+
+			if path != srcDir {
+				if d.Name() == "testdata" || d.Name() == "vendor" || strings.HasPrefix(d.Name(), ".") || strings.HasPrefix(d.Name(), "_") {
+					return filepath.SkipDir
+				}
+
+				// nested modules are discovered on their own
+				if _, err = os.Stat(filepath.Join(path, "go.mod")); err == nil && !workspace {
+					return filepath.SkipDir
+				}
+			}
+
+			return addTargets(path)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	slices.SortFunc(functions, func(a, b testFunction) int {
+		if c := strings.Compare(a.pkg, b.pkg); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.name, b.name)
+	})
+
+	return slices.Compact(functions), nil
+}
+
+// testFunctions returns the names of the test functions with the prefix declared in the file.
+func testFunctions(path, prefix string) ([]string, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Recv != nil || !strings.HasPrefix(fn.Name.Name, prefix) || len(fn.Type.Params.List) != 1 {
+			continue
+		}
+
+		// the parameter type is named after the prefix: *testing.F for fuzz tests, *testing.B for benchmarks
+		if star, ok := fn.Type.Params.List[0].Type.(*ast.StarExpr); ok {
+			if sel, ok := star.X.(*ast.SelectorExpr); ok && sel.Sel.Name == prefix[:1] {
+				names = append(names, fn.Name.Name)
+			}
+		}
+	}
+
+	return names, nil
+}
@@ -5,8 +5,12 @@
 package auto
 
 import (
+	"bufio"
+	"fmt"
+	"go/build/constraint"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	git "github.com/go-git/go-git/v5"
@@ -29,6 +33,103 @@ func listFilesWithSuffix(path, suffix string) ([]string, error) {
 	return result, nil
 }
 
+// listGoSourceFiles lists the Go files in the directory, skipping the files excluded from the build with `//go:build ignore`.
+func listGoSourceFiles(path string) ([]string, error) {
+	list, err := listFilesWithSuffix(path, ".go")
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(list))
+
+	for _, item := range list {
+		ignored, err := isIgnoredGoFile(filepath.Join(path, item))
+		if err != nil {
+			return nil, err
+		}
+
+		if !ignored {
+			result = append(result, item)
+		}
+	}
+
+	return result, nil
+}
+
+// isIgnoredGoFile checks whether the build constraint of the Go file can only be satisfied with the `ignore` tag.
+func isIgnoredGoFile(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case line == "":
+			continue
+		case constraint.IsGoBuild(line):
+			expr, err := constraint.Parse(line)
+			if err != nil {
+				return false, fmt.Errorf("%s: %w", path, err)
+			}
+
+			return !satisfiable(expr, "ignore"), nil
+		case strings.HasPrefix(line, "//"):
+			continue
+		default:
+			// build constraints must appear before the package clause
+			return false, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// satisfiable checks whether the expression evaluates to true for any set of tags, with the unset tag always false.
+func satisfiable(expr constraint.Expr, unset string) bool {
+	tags := slices.DeleteFunc(constraintTags(expr, nil), func(tag string) bool { return tag == unset })
+
+	// don't bother checking overly complex constraints
+	if len(tags) > 16 {
+		return true
+	}
+
+	for set := range 1 << len(tags) {
+		if expr.Eval(func(tag string) bool {
+			idx := slices.Index(tags, tag)
+
+			return idx != -1 && set&(1<<idx) != 0
+		}) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func constraintTags(expr constraint.Expr, tags []string) []string {
+	switch expr := expr.(type) {
+	case *constraint.TagExpr:
+		if !slices.Contains(tags, expr.Tag) {
+			tags = append(tags, expr.Tag)
+		}
+	case *constraint.NotExpr:
+		tags = constraintTags(expr.X, tags)
+	case *constraint.AndExpr:
+		tags = constraintTags(expr.Y, constraintTags(expr.X, tags))
+	case *constraint.OrExpr:
+		tags = constraintTags(expr.Y, constraintTags(expr.X, tags))
+	}
+
+	return tags
+}
+
 // trackedFiles returns the set of file names tracked in the git index of the
 // repository at rootPath, so kres wires only committed or staged files into
 // generated output and never a developer's personal, untracked file (for
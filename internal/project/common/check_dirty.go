@@ -8,6 +8,7 @@ import (
 	"github.com/siderolabs/kres/internal/config"
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -66,3 +67,20 @@ func (c *CheckDirty) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 
 	return nil
 }
+
+// CompileGitLabCI implements gitlabci.Compiler.
+func (c *CheckDirty) CompileGitLabCI(output *gitlabci.Output) error {
+	if c.meta.ContainerImageFrontend != config.ContainerImageFrontendDockerfile {
+		return nil
+	}
+
+	checkDirtyStep := gitlabci.MakeStep("check-dirty")
+
+	if err := checkDirtyStep.SetConditions("on-pull-request"); err != nil {
+		return err
+	}
+
+	output.AddStep(gitlabci.DefaultJobName, checkDirtyStep)
+
+	return nil
+}
@@ -5,10 +5,17 @@
 package common
 
 import (
+	"cmp"
 	"fmt"
+	"strings"
+
+	"github.com/siderolabs/gen/xslices"
 
 	"github.com/siderolabs/kres/internal/config"
 	"github.com/siderolabs/kres/internal/dag"
+	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitignore"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -22,6 +29,30 @@ type Docker struct { //nolint:govet
 	DockerImage    string   `yaml:"dockerImage"`
 	AllowInsecure  bool     `yaml:"allowInsecure"`
 	ExtraBuildArgs []string `yaml:"extraBuildArgs"`
+
+	Cache DockerCache `yaml:"cache"`
+}
+
+// Build cache backends supported by [DockerCache].
+const (
+	DockerCacheRegistry = "registry"
+	DockerCacheGHA      = "gha"
+	DockerCacheLocal    = "local"
+)
+
+// DockerCache configures the remote build cache for buildx targets.
+//
+// Each target gets its own cache scope, so that building one target doesn't evict the cache of the others.
+// The cache arguments can be overridden with CACHE_FROM and CACHE_TO variables, setting them to empty value disables the cache.
+type DockerCache struct {
+	// Type is the cache backend: registry, gha or local; empty disables the remote cache.
+	Type string `yaml:"type"`
+	// Ref is the registry cache image, defaults to $(REGISTRY_AND_USERNAME)/<repository>-cache.
+	Ref string `yaml:"ref"`
+	// Dir is the local cache directory, defaults to .buildx-cache.
+	Dir string `yaml:"dir"`
+	// Mode is the cache export mode: min or max.
+	Mode string `yaml:"mode"`
 }
 
 // NewDocker initializes Docker.
@@ -34,6 +65,89 @@ func NewDocker(meta *meta.Options) *Docker {
 		meta: meta,
 
 		DockerImage: "docker:" + config.DindContainerImageVersion,
+
+		Cache: DockerCache{
+			Dir:  ".buildx-cache",
+			Mode: "max",
+		},
+	}
+}
+
+// AfterLoad validates the build cache configuration.
+func (docker *Docker) AfterLoad() error {
+	switch docker.Cache.Type {
+	case "", DockerCacheRegistry, DockerCacheGHA, DockerCacheLocal:
+	default:
+		return fmt.Errorf("unsupported build cache type %q, expected one of %q, %q, %q", docker.Cache.Type, DockerCacheRegistry, DockerCacheGHA, DockerCacheLocal)
+	}
+
+	switch docker.Cache.Mode {
+	case "min", "max":
+	default:
+		return fmt.Errorf("unsupported build cache mode %q, expected min or max", docker.Cache.Mode)
+	}
+
+	switch docker.Cache.Type {
+	case DockerCacheGHA:
+		// the gha cache needs the runtime token exposed with actions/github-script, which is GitHub Actions specific
+		if docker.meta.CIProvider != "" && docker.meta.CIProvider != meta.CIProviderGitHub {
+			return fmt.Errorf("build cache type %q is not supported with %s CI provider, use %q or %q",
+				DockerCacheGHA, docker.meta.CIProvider, DockerCacheRegistry, DockerCacheLocal)
+		}
+	case DockerCacheRegistry:
+		// GitHub Actions workflows log in to ghcr.io with the workflow token, GitLab CI logs in with the job token to the cache registry
+		if docker.Cache.Ref != "" && docker.meta.CIProvider != meta.CIProviderGitLab && docker.cacheRegistry() != "ghcr.io" {
+			return fmt.Errorf("build cache ref %q is not supported with %s CI provider, the cache registry should be ghcr.io",
+				docker.Cache.Ref, cmp.Or(docker.meta.CIProvider, meta.CIProviderGitHub))
+		}
+	}
+
+	return nil
+}
+
+// cacheRegistry returns the registry of the build cache ref.
+func (docker *Docker) cacheRegistry() string {
+	if docker.Cache.Ref == "" {
+		return ""
+	}
+
+	host, _, ok := strings.Cut(docker.Cache.Ref, "/")
+	if !ok || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		return "docker.io"
+	}
+
+	return host
+}
+
+// cacheVariables returns the build cache variables for the configured backend.
+func (docker *Docker) cacheVariables() []*makefile.Variable {
+	mode := ",mode=" + docker.Cache.Mode
+
+	switch docker.Cache.Type {
+	case DockerCacheRegistry:
+		ref := docker.Cache.Ref
+		if ref == "" {
+			ref = "$(REGISTRY_AND_USERNAME)/" + docker.meta.GitHubRepository + "-cache"
+		}
+
+		return []*makefile.Variable{
+			makefile.OverridableVariable("CACHE_REF", ref),
+			makefile.OverridableVariable("CACHE_FROM", "type=registry,ref=$(CACHE_REF):$*"),
+			makefile.OverridableVariable("CACHE_TO", "type=registry,ref=$(CACHE_REF):$*"+mode),
+		}
+	case DockerCacheGHA:
+		return []*makefile.Variable{
+			makefile.OverridableVariable("CACHE_FROM", "type=gha,scope=$*"),
+			makefile.OverridableVariable("CACHE_TO", "type=gha,scope=$*"+mode),
+		}
+	case DockerCacheLocal:
+		return []*makefile.Variable{
+			makefile.OverridableVariable("CACHE_DIR", docker.Cache.Dir),
+			makefile.OverridableVariable("CACHE_FROM", "type=local,src=$(CACHE_DIR)/$*"),
+			makefile.OverridableVariable("CACHE_TO", "type=local,dest=$(CACHE_DIR)/$*"+mode),
+		}
+	default:
+		return nil
 	}
 }
 
@@ -65,9 +179,17 @@ func (docker *Docker) CompileMakefile(output *makefile.Output) error {
 		Variable(makefile.OverridableVariable("USERNAME", docker.meta.GitHubOrganization)).
 		Variable(makefile.OverridableVariable("REGISTRY_AND_USERNAME", "$(REGISTRY)/$(USERNAME)"))
 
+	platforms := xslices.Filter(docker.meta.Platforms, func(platform string) bool {
+		return strings.HasPrefix(platform, "linux/")
+	})
+
+	if len(platforms) == 0 {
+		platforms = []string{"linux/amd64"}
+	}
+
 	output.VariableGroup(makefile.VariableGroupDocker).
 		Variable(makefile.SimpleVariable("BUILD", "docker buildx build")).
-		Variable(makefile.OverridableVariable("PLATFORM", "linux/amd64")).
+		Variable(makefile.OverridableVariable("PLATFORM", strings.Join(platforms, ","))).
 		Variable(makefile.OverridableVariable("PROGRESS", "auto")).
 		Variable(makefile.OverridableVariable("PUSH", "false")).
 		Variable(makefile.OverridableVariable("CI_ARGS", "")).
@@ -75,6 +197,20 @@ func (docker *Docker) CompileMakefile(output *makefile.Output) error {
 		Variable(makefile.OverridableVariable("BUILDKIT_MULTI_PLATFORM", "")).
 		Variable(buildArgs)
 
+	targetScript := `@$(BUILD) --target=$* $(COMMON_ARGS) $(TARGET_ARGS) $(CI_ARGS) .`
+
+	if cacheVariables := docker.cacheVariables(); cacheVariables != nil {
+		group := output.VariableGroup(makefile.VariableGroupDocker)
+
+		for _, variable := range cacheVariables {
+			group.Variable(variable)
+		}
+
+		group.Variable(makefile.RecursiveVariable("CACHE_ARGS", "$(if $(CACHE_FROM),--cache-from=$(CACHE_FROM)) $(if $(CACHE_TO),--cache-to=$(CACHE_TO))"))
+
+		targetScript = `@$(BUILD) --target=$* $(COMMON_ARGS) $(CACHE_ARGS) $(TARGET_ARGS) $(CI_ARGS) .`
+	}
+
 	output.IfTrueCondition("WITH_BUILD_DEBUG").
 		Then(
 			makefile.SimpleVariable("BUILD", "BUILDX_EXPERIMENTAL=1 docker buildx debug --invoke /bin/sh --on error build"),
@@ -82,7 +218,7 @@ func (docker *Docker) CompileMakefile(output *makefile.Output) error {
 
 	output.Target("target-%").
 		Description("Builds the specified target defined in the Dockerfile. The build result will only remain in the build cache.").
-		Script(`@$(BUILD) --target=$* $(COMMON_ARGS) $(TARGET_ARGS) $(CI_ARGS) .`)
+		Script(targetScript)
 
 	output.Target("registry-%").
 		Description("Builds the specified target defined in the Dockerfile and the output is an image. The image is pushed to the registry if PUSH=true.").
@@ -94,3 +230,42 @@ func (docker *Docker) CompileMakefile(output *makefile.Output) error {
 
 	return nil
 }
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (docker *Docker) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	if docker.meta.ContainerImageFrontend != config.ContainerImageFrontendDockerfile {
+		return nil
+	}
+
+	switch docker.Cache.Type {
+	case DockerCacheGHA:
+		output.SetBuildCache(ghworkflow.BuildCacheGHA)
+	case DockerCacheRegistry:
+		output.SetBuildCache(ghworkflow.BuildCacheRegistry)
+	}
+
+	return nil
+}
+
+// CompileGitLabCI implements gitlabci.Compiler.
+func (docker *Docker) CompileGitLabCI(output *gitlabci.Output) error {
+	if docker.meta.ContainerImageFrontend != config.ContainerImageFrontendDockerfile {
+		return nil
+	}
+
+	if docker.Cache.Type == DockerCacheRegistry {
+		// the default cache ref is in $(REGISTRY), which is the project container registry
+		output.SetBuildCacheRegistry(cmp.Or(docker.cacheRegistry(), "${CI_REGISTRY}"))
+	}
+
+	return nil
+}
+
+// CompileGitignore implements gitignore.Compiler.
+func (docker *Docker) CompileGitignore(output *gitignore.Output) error {
+	if docker.Cache.Type == DockerCacheLocal {
+		output.IgnorePath(docker.Cache.Dir)
+	}
+
+	return nil
+}
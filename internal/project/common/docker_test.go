@@ -5,14 +5,194 @@
 package common_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitignore"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/common"
+	"github.com/siderolabs/kres/internal/project/meta"
 )
 
 func TestDockerInterfaces(t *testing.T) {
 	assert.Implements(t, (*makefile.Compiler)(nil), new(common.Docker))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(common.Docker))
+	assert.Implements(t, (*gitignore.Compiler)(nil), new(common.Docker))
+	assert.Implements(t, (*gitlabci.Compiler)(nil), new(common.Docker))
+}
+
+func TestDockerPlatforms(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		platforms []string
+		want      string
+	}{
+		{
+			name: "default",
+			want: "PLATFORM ?= linux/amd64\n",
+		},
+		{
+			name:      "linux only",
+			platforms: []string{"linux/amd64", "linux/arm64", "darwin/arm64"},
+			want:      "PLATFORM ?= linux/amd64,linux/arm64\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			docker := common.NewDocker(&meta.Options{
+				ContainerImageFrontend: config.ContainerImageFrontendDockerfile,
+				Platforms:              tc.platforms,
+			})
+
+			output := makefile.NewOutput()
+
+			require.NoError(t, docker.CompileMakefile(output))
+
+			var buf bytes.Buffer
+
+			require.NoError(t, output.GenerateFile("Makefile", &buf))
+			assert.Contains(t, buf.String(), tc.want)
+		})
+	}
+}
+
+func TestDockerCache(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		cache common.DockerCache
+		want  []string
+	}{
+		{
+			name: "disabled",
+			want: []string{
+				"\t@$(BUILD) --target=$* $(COMMON_ARGS) $(TARGET_ARGS) $(CI_ARGS) .\n",
+			},
+		},
+		{
+			name:  "registry",
+			cache: common.DockerCache{Type: common.DockerCacheRegistry, Mode: "max"},
+			want: []string{
+				"CACHE_REF ?= $(REGISTRY_AND_USERNAME)/kres-cache\n",
+				"CACHE_FROM ?= type=registry,ref=$(CACHE_REF):$*\n",
+				"CACHE_TO ?= type=registry,ref=$(CACHE_REF):$*,mode=max\n",
+				"\t@$(BUILD) --target=$* $(COMMON_ARGS) $(CACHE_ARGS) $(TARGET_ARGS) $(CI_ARGS) .\n",
+			},
+		},
+		{
+			name:  "gha",
+			cache: common.DockerCache{Type: common.DockerCacheGHA, Mode: "min"},
+			want: []string{
+				"CACHE_FROM ?= type=gha,scope=$*\n",
+				"CACHE_TO ?= type=gha,scope=$*,mode=min\n",
+				"CACHE_ARGS = $(if $(CACHE_FROM),--cache-from=$(CACHE_FROM)) $(if $(CACHE_TO),--cache-to=$(CACHE_TO))\n",
+			},
+		},
+		{
+			name:  "local",
+			cache: common.DockerCache{Type: common.DockerCacheLocal, Dir: "_cache", Mode: "max"},
+			want: []string{
+				"CACHE_DIR ?= _cache\n",
+				"CACHE_FROM ?= type=local,src=$(CACHE_DIR)/$*\n",
+				"CACHE_TO ?= type=local,dest=$(CACHE_DIR)/$*,mode=max\n",
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			docker := common.NewDocker(&meta.Options{
+				ContainerImageFrontend: config.ContainerImageFrontendDockerfile,
+				GitHubRepository:       "kres",
+			})
+			docker.Cache = tc.cache
+
+			output := makefile.NewOutput()
+
+			require.NoError(t, docker.CompileMakefile(output))
+
+			var buf bytes.Buffer
+
+			require.NoError(t, output.GenerateFile("Makefile", &buf))
+
+			for _, want := range tc.want {
+				assert.Contains(t, buf.String(), want)
+			}
+
+			if tc.cache.Type == "" {
+				assert.NotContains(t, buf.String(), "CACHE_")
+			}
+		})
+	}
+}
+
+func TestDockerCacheInvalid(t *testing.T) {
+	docker := common.NewDocker(&meta.Options{})
+	docker.Cache.Type = "s3"
+
+	assert.EqualError(t, docker.AfterLoad(), `unsupported build cache type "s3", expected one of "registry", "gha", "local"`)
+
+	docker.Cache.Type = common.DockerCacheGHA
+	docker.Cache.Mode = "all"
+
+	assert.EqualError(t, docker.AfterLoad(), `unsupported build cache mode "all", expected min or max`)
+
+	docker = common.NewDocker(&meta.Options{CIProvider: meta.CIProviderForgejo})
+	docker.Cache.Type = common.DockerCacheGHA
+
+	assert.EqualError(t, docker.AfterLoad(), `build cache type "gha" is not supported with forgejo CI provider, use "registry" or "local"`)
+
+	docker = common.NewDocker(&meta.Options{CIProvider: meta.CIProviderGitLab})
+	docker.Cache.Type = common.DockerCacheGHA
+
+	assert.EqualError(t, docker.AfterLoad(), `build cache type "gha" is not supported with gitlab CI provider, use "registry" or "local"`)
+
+	docker = common.NewDocker(&meta.Options{CIProvider: meta.CIProviderGitHub})
+	docker.Cache.Type = common.DockerCacheRegistry
+	docker.Cache.Ref = "registry.example.com/org/cache"
+
+	assert.EqualError(t, docker.AfterLoad(), `build cache ref "registry.example.com/org/cache" is not supported with github CI provider, the cache registry should be ghcr.io`)
+
+	docker.Cache.Ref = "ghcr.io/org/cache"
+
+	assert.NoError(t, docker.AfterLoad())
+}
+
+func TestDockerCacheGitLab(t *testing.T) {
+	for _, tc := range []struct {
+		ref      string
+		expected string
+	}{
+		{
+			expected: `echo "${CI_REGISTRY_PASSWORD}" | docker login -u "${CI_REGISTRY_USER}" --password-stdin "${CI_REGISTRY}"`,
+		},
+		{
+			ref:      "registry.example.com/org/cache",
+			expected: `echo "${CI_REGISTRY_PASSWORD}" | docker login -u "${CI_REGISTRY_USER}" --password-stdin "registry.example.com"`,
+		},
+	} {
+		t.Run(tc.ref, func(t *testing.T) {
+			docker := common.NewDocker(&meta.Options{
+				CIProvider:             meta.CIProviderGitLab,
+				ContainerImageFrontend: config.ContainerImageFrontendDockerfile,
+			})
+			docker.Cache.Type = common.DockerCacheRegistry
+			docker.Cache.Ref = tc.ref
+
+			require.NoError(t, docker.AfterLoad())
+
+			output := gitlabci.NewOutput("main")
+
+			require.NoError(t, docker.CompileGitLabCI(output))
+
+			var buf bytes.Buffer
+
+			require.NoError(t, output.GenerateFile(".gitlab-ci.yml", &buf))
+
+			assert.Contains(t, buf.String(), "    - "+tc.expected+"\n")
+			assert.Contains(t, buf.String(), `    - if [ "${CI_PIPELINE_SOURCE}" = "merge_request_event" ]; then export CACHE_TO=; fi`+"\n")
+		})
+	}
 }
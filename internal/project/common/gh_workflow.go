@@ -17,6 +17,7 @@ import (
 	"github.com/siderolabs/kres/internal/config"
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
 
@@ -1164,3 +1165,11 @@ func injectTriggeredRunID(steps []*ghworkflow.JobStep) []*ghworkflow.JobStep {
 
 	return result
 }
+
+// CompileGitLabCI implements gitlabci.Compiler.
+//
+// The runner group and the workflows are GitHub Actions specific, the custom workflows
+// are only compiled with compileGHWorkflowsOnly, which is not supported with GitLab CI.
+func (gh *GHWorkflow) CompileGitLabCI(*gitlabci.Output) error {
+	return nil
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/siderolabs/kres/internal/output/dockerfile/step"
 	"github.com/siderolabs/kres/internal/output/dockerignore"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -160,6 +161,53 @@ func (image *Image) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 	return nil
 }
 
+// CompileGitLabCI implements gitlabci.Compiler.
+func (image *Image) CompileGitLabCI(output *gitlabci.Output) error {
+	loginStep := gitlabci.LoginStep()
+
+	if err := loginStep.SetConditions("except-pull-request"); err != nil {
+		return err
+	}
+
+	pushStep := gitlabci.MakeStep(image.Name()).
+		SetEnv("PUSH", "true")
+
+	if err := pushStep.SetConditions("except-pull-request"); err != nil {
+		return err
+	}
+
+	for k, v := range image.ExtraEnvironment {
+		pushStep.SetEnv(k, v)
+	}
+
+	steps := []*gitlabci.Step{
+		loginStep,
+		gitlabci.MakeStep(image.Name()),
+		pushStep,
+	}
+
+	if image.PushLatest {
+		pushStep := gitlabci.MakeStep(image.Name(), "IMAGE_TAG=latest").
+			SetEnv("PUSH", "true")
+
+		if err := pushStep.SetConditions("except-pull-request"); err != nil {
+			return err
+		}
+
+		pushStep.SetConditionOnlyOnBranch(image.meta.MainBranch)
+
+		for k, v := range image.ExtraEnvironment {
+			pushStep.SetEnv(k, v)
+		}
+
+		steps = append(steps, pushStep)
+	}
+
+	output.AddStep(gitlabci.DefaultJobName, steps...)
+
+	return nil
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (image *Image) CompileMakefile(output *makefile.Output) error {
 	target := output.Target(image.Name()).
@@ -11,6 +11,7 @@ import (
 
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/lefthook"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
@@ -59,6 +60,17 @@ func (lint *Lint) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 	return nil
 }
 
+// CompileGitLabCI implements gitlabci.Compiler.
+func (lint *Lint) CompileGitLabCI(output *gitlabci.Output) error {
+	output.AddStepInParallelJob(
+		"lint",
+		nil,
+		gitlabci.MakeStep("lint"),
+	)
+
+	return nil
+}
+
 // LinterHasFmt is implemented by linters that have a formatting step.
 type LinterHasFmt interface {
 	LinterHasFmt()
@@ -15,6 +15,7 @@ import (
 	"github.com/siderolabs/kres/internal/config"
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -63,11 +64,7 @@ func (release *Release) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 		SetWith("draft", "true")
 
 	if len(release.meta.Commands) > 0 {
-		releaseArtifacts := slices.Clone(release.Artifacts)
-		// gather extra artifacts contributed by input nodes
-		for _, node := range dag.GatherMatchingInputs(release, dag.Implements[ReleaseArtifactsProvider]()) {
-			releaseArtifacts = append(releaseArtifacts, node.(ReleaseArtifactsProvider).ReleaseArtifacts()...) //nolint:forcetypeassert,errcheck
-		}
+		releaseArtifacts := release.releaseArtifacts()
 
 		artifacts := xslices.Map(releaseArtifacts, func(artifact string) string {
 			return filepath.Join(release.meta.ArtifactsPath, artifact)
@@ -137,6 +134,88 @@ func (release *Release) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 	return nil
 }
 
+// CompileGitLabCI implements gitlabci.Compiler.
+func (release *Release) CompileGitLabCI(output *gitlabci.Output) error {
+	releaseNotesStep := gitlabci.MakeStep("release-notes")
+
+	if err := releaseNotesStep.SetConditions("only-on-tag"); err != nil {
+		return err
+	}
+
+	steps := []*gitlabci.Step{releaseNotesStep}
+
+	links := []gitlabci.ReleaseLink{}
+
+	if len(release.meta.Commands) > 0 {
+		releaseArtifacts := release.releaseArtifacts()
+
+		checkSumStep := gitlabci.Command(fmt.Sprintf(
+			"(cd %s && sha256sum %s > sha256sum.txt && sha512sum %s > sha512sum.txt)",
+			release.meta.ArtifactsPath,
+			strings.Join(releaseArtifacts, " "),
+			strings.Join(releaseArtifacts, " "),
+		))
+
+		if err := checkSumStep.SetConditions("only-on-tag"); err != nil {
+			return err
+		}
+
+		steps = append(steps, checkSumStep)
+
+		// GitLab release links can't use patterns, so the artifacts are linked as the archive of the default job
+		links = append(links, gitlabci.ReleaseLink{
+			Name: "artifacts",
+			URL:  "${CI_PROJECT_URL}/-/jobs/artifacts/${CI_COMMIT_TAG}/download?job=" + gitlabci.DefaultJobName,
+		})
+
+		for _, checksum := range []string{"sha256sum.txt", "sha512sum.txt"} {
+			links = append(links, gitlabci.ReleaseLink{
+				Name: checksum,
+				URL:  fmt.Sprintf("${CI_PROJECT_URL}/-/jobs/artifacts/${CI_COMMIT_TAG}/raw/%s?job=%s", filepath.Join(release.meta.ArtifactsPath, checksum), gitlabci.DefaultJobName),
+			})
+		}
+	}
+
+	output.AddStep(gitlabci.DefaultJobName, steps...)
+	output.AddArtifacts(gitlabci.DefaultJobName, "", release.meta.ArtifactsPath)
+
+	job := &gitlabci.Job{
+		Stage:   gitlabci.StageRelease,
+		Image:   "registry.gitlab.com/gitlab-org/release-cli:" + config.GitLabReleaseCLIImageVersion,
+		Inherit: &gitlabci.Inherit{Default: false},
+		Needs:   gitlabci.NeedJobs(gitlabci.DefaultJobName),
+		Script:  []string{`echo "Releasing ${CI_COMMIT_TAG}"`},
+		Release: &gitlabci.Release{
+			TagName:     "$CI_COMMIT_TAG",
+			Name:        "$CI_COMMIT_TAG",
+			Description: "./" + filepath.Join(release.meta.ArtifactsPath, "RELEASE_NOTES.md"),
+		},
+	}
+
+	if len(links) > 0 {
+		job.Release.Assets = &gitlabci.ReleaseAssets{Links: links}
+	}
+
+	if err := job.SetConditions("only-on-tag"); err != nil {
+		return err
+	}
+
+	output.AddJob("release", job)
+
+	return nil
+}
+
+// releaseArtifacts returns the list of release file patterns including the ones contributed by input nodes.
+func (release *Release) releaseArtifacts() []string {
+	releaseArtifacts := slices.Clone(release.Artifacts)
+	// gather extra artifacts contributed by input nodes
+	for _, node := range dag.GatherMatchingInputs(release, dag.Implements[ReleaseArtifactsProvider]()) {
+		releaseArtifacts = append(releaseArtifacts, node.(ReleaseArtifactsProvider).ReleaseArtifacts()...) //nolint:forcetypeassert,errcheck
+	}
+
+	return releaseArtifacts
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (release *Release) CompileMakefile(output *makefile.Output) error {
 	output.Target("release-notes").
@@ -20,6 +20,7 @@ import (
 	"github.com/siderolabs/kres/internal/output"
 	"github.com/siderolabs/kres/internal/output/conform"
 	"github.com/siderolabs/kres/internal/output/conform/licensepolicy"
+	ghoutput "github.com/siderolabs/kres/internal/output/github"
 	"github.com/siderolabs/kres/internal/output/lefthook"
 	"github.com/siderolabs/kres/internal/output/license"
 	"github.com/siderolabs/kres/internal/project/meta"
@@ -111,7 +112,7 @@ func NewRepository(meta *meta.Options) *Repository {
 		Licenses: []LicenseConfig{
 			{
 				ID:     "MPL-2.0",
-				Header: output.License(MPLHeader, "// "),
+				Header: output.License(output.MPLHeader, "// "),
 				Root:   ".",
 			},
 		},
@@ -200,11 +201,17 @@ func (r *Repository) CompileLicense(o *license.Output) error {
 	return nil
 }
 
-// CompileGitHub implements github.Compiler. When Repository.DryRun is true,
-// intended changes are logged instead of applied. When the client is nil
-// (GITHUB_TOKEN unset) and DryRun is false, GitHub API integration is
-// skipped entirely.
-func (r *Repository) CompileGitHub(client *github.Client) error {
+// CompileGitHub implements github.Compiler. When Repository.DryRun is true
+// or the output is in dry-run mode, intended changes are logged instead of
+// applied. When the client is nil (GITHUB_TOKEN unset) and dry-run is off,
+// GitHub API integration is skipped entirely.
+func (r *Repository) CompileGitHub(o *ghoutput.Output) error {
+	client := o.Client()
+
+	if o.DryRun() {
+		r.DryRun = true
+	}
+
 	if client == nil && !r.DryRun {
 		return nil
 	}
@@ -634,8 +641,3 @@ func equalStringSlices(a, b []string) bool {
 
 	return slices.Equal(a, b)
 }
-
-// MPLHeader is the Mozilla Public License 2.0 header.
-const MPLHeader = `This Source Code Form is subject to the terms of the Mozilla Public
-License, v. 2.0. If a copy of the MPL was not distributed with this
-file, You can obtain one at http://mozilla.org/MPL/2.0/.`
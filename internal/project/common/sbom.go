@@ -13,6 +13,7 @@ import (
 	"github.com/siderolabs/kres/internal/output/dockerfile"
 	"github.com/siderolabs/kres/internal/output/dockerfile/step"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -148,6 +149,23 @@ func (sbom *SBOM) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 	return nil
 }
 
+// CompileGitLabCI implements gitlabci.Compiler.
+func (sbom *SBOM) CompileGitLabCI(output *gitlabci.Output) error {
+	if !sbom.Enabled {
+		return nil
+	}
+
+	sbomStep := gitlabci.MakeStep("sbom")
+
+	if err := sbomStep.SetConditions("only-on-tag"); err != nil {
+		return err
+	}
+
+	output.AddStep(gitlabci.DefaultJobName, sbomStep)
+
+	return nil
+}
+
 // ReleaseArtifacts implements common.ReleaseArtifactsProvider: the SBOM files are uploaded with the release.
 func (sbom *SBOM) ReleaseArtifacts() []string {
 	if !sbom.Enabled {
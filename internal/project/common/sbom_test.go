@@ -35,6 +35,10 @@ func sbomDockerfile(t *testing.T, opts *meta.Options) string {
 
 	var output dockerfile.Output
 
+	// stages provided by the toolchains
+	output.Stage("base").From("scratch")
+	output.Stage("js").From("scratch")
+
 	require.NoError(t, sbom.CompileDockerfile(&output))
 
 	var buf bytes.Buffer
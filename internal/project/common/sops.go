@@ -5,8 +5,11 @@
 package common
 
 import (
+	"fmt"
+
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/sops"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -61,3 +64,13 @@ func (sops *SOPS) CompileGitHubWorkflow(o *ghworkflow.Output) error {
 
 	return nil
 }
+
+// CompileGitLabCI implements gitlabci.Compiler.
+func (sops *SOPS) CompileGitLabCI(*gitlabci.Output) error {
+	if !sops.Enabled {
+		return nil
+	}
+
+	// the secrets are decrypted with the keys provisioned on the GitHub Actions runners
+	return fmt.Errorf("sops is not supported with %s CI provider", meta.CIProviderGitLab)
+}
@@ -0,0 +1,136 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/siderolabs/kres/internal/dag"
+	"github.com/siderolabs/kres/internal/output/template"
+	"github.com/siderolabs/kres/internal/project/meta"
+)
+
+// Templates generates user-defined files from Go templates, e.g. CODEOWNERS, SECURITY.md or .editorconfig.
+//
+// The templates are executed with the project [meta.Options] as params, so the files can refer to
+// the organization, repository, commands or the main branch, e.g. {{ .GitHubRepository }}.
+type Templates struct {
+	dag.BaseNode
+
+	meta *meta.Options
+
+	Files []TemplateFile `yaml:"files"`
+}
+
+// TemplateFile is a single file generated from a template.
+type TemplateFile struct { //nolint:govet
+	// Path is the repository-relative path of the generated file.
+	Path string `yaml:"path"`
+	// Template is the inline template.
+	Template string `yaml:"template"`
+	// TemplatePath is the repository-relative path to the template, used instead of the inline template.
+	TemplatePath string `yaml:"templatePath"`
+	// NoOverwrite generates the file only if it doesn't exist yet.
+	NoOverwrite bool `yaml:"noOverwrite"`
+	// License prepends the license header, MPL by default.
+	License bool `yaml:"license"`
+	// LicenseText overrides the license header text.
+	LicenseText string `yaml:"licenseText"`
+	// Preamble prepends the "automatically generated" notice.
+	Preamble bool `yaml:"preamble"`
+	// CommentPrefix is the comment prefix for the license header and the preamble, "# " by default.
+	CommentPrefix string `yaml:"commentPrefix"`
+}
+
+// NewTemplates initializes Templates.
+func NewTemplates(meta *meta.Options) *Templates {
+	return &Templates{
+		BaseNode: dag.NewBaseNode("templates"),
+
+		meta: meta,
+	}
+}
+
+// AfterLoad validates the templates and loads the repository-relative ones.
+func (templates *Templates) AfterLoad() error {
+	seen := map[string]struct{}{}
+
+	for i := range templates.Files {
+		file := &templates.Files[i]
+
+		if !isRelativePath(file.Path) {
+			return fmt.Errorf("template file path %q must be a relative path inside the repository", file.Path)
+		}
+
+		file.Path = path.Clean(file.Path)
+
+		if _, ok := seen[file.Path]; ok {
+			return fmt.Errorf("template file %q is defined more than once", file.Path)
+		}
+
+		seen[file.Path] = struct{}{}
+
+		switch {
+		case file.Template != "" && file.TemplatePath != "":
+			return fmt.Errorf("template file %q: template and templatePath are mutually exclusive", file.Path)
+		case file.TemplatePath != "":
+			if !isRelativePath(file.TemplatePath) {
+				return fmt.Errorf("template file %q: templatePath %q must be a relative path inside the repository", file.Path, file.TemplatePath)
+			}
+
+			contents, err := os.ReadFile(file.TemplatePath)
+			if err != nil {
+				return fmt.Errorf("template file %q: %w", file.Path, err)
+			}
+
+			file.Template = string(contents)
+		case file.Template == "":
+			return fmt.Errorf("template file %q: either template or templatePath should be set", file.Path)
+		}
+
+		if _, err := texttemplate.New(file.Path).Parse(file.Template); err != nil {
+			return fmt.Errorf("template file %q: %w", file.Path, err)
+		}
+
+		if file.CommentPrefix == "" {
+			file.CommentPrefix = "# "
+		}
+	}
+
+	return nil
+}
+
+// CompileTemplates implements [template.Compiler].
+func (templates *Templates) CompileTemplates(output *template.Output) error {
+	for _, file := range templates.Files {
+		t := output.Define(file.Path, file.Template).
+			Params(templates.meta).
+			PreamblePrefix(file.CommentPrefix)
+
+		if !file.Preamble {
+			t.NoPreamble()
+		}
+
+		if file.License {
+			t.WithLicense().WithLicenseText(file.LicenseText)
+		}
+
+		if file.NoOverwrite {
+			t.NoOverwrite()
+		}
+	}
+
+	return nil
+}
+
+func isRelativePath(p string) bool {
+	p = path.Clean(p)
+
+	return p != "." && p != ".." && !path.IsAbs(p) && !strings.HasPrefix(p, "../")
+}
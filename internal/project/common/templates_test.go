@@ -0,0 +1,119 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package common_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/internal/output"
+	"github.com/siderolabs/kres/internal/output/template"
+	"github.com/siderolabs/kres/internal/project/common"
+	"github.com/siderolabs/kres/internal/project/meta"
+)
+
+func TestTemplatesInterfaces(t *testing.T) {
+	assert.Implements(t, (*template.Compiler)(nil), new(common.Templates))
+}
+
+func TestTemplates(t *testing.T) {
+	output.PreambleTimestamp, _ = time.Parse(time.RFC3339, strings.ReplaceAll(time.RFC3339, "07:00", "")) //nolint:errcheck
+	output.PreambleCreator = "test"
+
+	t.Chdir(t.TempDir())
+
+	require.NoError(t, os.MkdirAll(".kres", 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(".kres", "SECURITY.md.tmpl"), []byte("Report issues to {{ .GitHubOrganization }}.\n"), 0o644))
+
+	templates := common.NewTemplates(&meta.Options{
+		GitHubOrganization: "siderolabs",
+		GitHubRepository:   "kres",
+		MainBranch:         "main",
+		Commands:           []meta.Command{{Name: "kres"}},
+	})
+	templates.Files = []common.TemplateFile{
+		{
+			Path:     ".github/CODEOWNERS",
+			Template: "* @{{ .GitHubOrganization }}/{{ .GitHubRepository }}-maintainers\n",
+			Preamble: true,
+		},
+		{
+			Path:         "./SECURITY.md",
+			TemplatePath: ".kres/SECURITY.md.tmpl",
+			NoOverwrite:  true,
+		},
+		{
+			Path:          "hack/branch.go",
+			Template:      "package hack\n\nconst branch = {{ printf \"%q\" .MainBranch }}\n{{ range .Commands }}// {{ .Name }}\n{{ end }}",
+			License:       true,
+			CommentPrefix: "// ",
+		},
+	}
+
+	require.NoError(t, templates.AfterLoad())
+
+	o := template.NewOutput()
+
+	require.NoError(t, templates.CompileTemplates(o))
+
+	assert.Equal(t, []string{".github/CODEOWNERS", "SECURITY.md", "hack/branch.go"}, o.Filenames())
+	assert.Equal(t, []string{".github/CODEOWNERS", "hack/branch.go"}, o.ManagedFilenames())
+
+	for filename, expected := range map[string]string{
+		".github/CODEOWNERS": "# THIS FILE WAS AUTOMATICALLY GENERATED BY KRES, PLEASE DO NOT EDIT.\n#\n# Generated on 2006-01-02T15:04:05Z by test.\n\n" +
+			"* @siderolabs/kres-maintainers\n",
+		"SECURITY.md": "Report issues to siderolabs.\n",
+		"hack/branch.go": "// " + strings.ReplaceAll(output.MPLHeader, "\n", "\n// ") + "\n\n" +
+			"package hack\n\nconst branch = \"main\"\n// kres\n",
+	} {
+		var buf bytes.Buffer
+
+		require.NoError(t, o.GenerateFile(filename, &buf))
+		assert.Equal(t, expected, buf.String(), filename)
+	}
+}
+
+func TestTemplatesInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		file  common.TemplateFile
+		error string
+	}{
+		{
+			name:  "outside",
+			file:  common.TemplateFile{Path: "../CODEOWNERS", Template: "x"},
+			error: `template file path "../CODEOWNERS" must be a relative path inside the repository`,
+		},
+		{
+			name:  "no template",
+			file:  common.TemplateFile{Path: "CODEOWNERS"},
+			error: `template file "CODEOWNERS": either template or templatePath should be set`,
+		},
+		{
+			name:  "both",
+			file:  common.TemplateFile{Path: "CODEOWNERS", Template: "x", TemplatePath: "y"},
+			error: `template file "CODEOWNERS": template and templatePath are mutually exclusive`,
+		},
+		{
+			name:  "parse",
+			file:  common.TemplateFile{Path: "CODEOWNERS", Template: "{{ .Foo"},
+			error: `template file "CODEOWNERS": template: CODEOWNERS:1: unclosed action`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			templates := common.NewTemplates(&meta.Options{})
+			templates.Files = []common.TemplateFile{tc.file}
+
+			assert.EqualError(t, templates.AfterLoad(), tc.error)
+		})
+	}
+}
@@ -7,6 +7,7 @@ package custom
 
 import (
 	"fmt"
+	"regexp"
 	"slices"
 	"strings"
 
@@ -17,6 +18,7 @@ import (
 	"github.com/siderolabs/kres/internal/output/dockerfile"
 	dockerstep "github.com/siderolabs/kres/internal/output/dockerfile/step"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/lefthook"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
@@ -527,6 +529,128 @@ func (step *Step) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 	return nil
 }
 
+// CompileGitLabCI implements gitlabci.Compiler.
+//
+//nolint:gocognit,gocyclo,cyclop
+func (step *Step) CompileGitLabCI(output *gitlabci.Output) error {
+	if !step.GHAction.Enabled {
+		return nil
+	}
+
+	makeStep := func(env ...map[string]string) *gitlabci.Step {
+		target := step.Name()
+		if !step.Makefile.Enabled && step.MakeTarget != "" {
+			target = step.MakeTarget
+		}
+
+		workflowStep := gitlabci.MakeStep(target)
+
+		for _, vars := range env {
+			for k, v := range vars {
+				workflowStep.SetEnv(k, v)
+			}
+		}
+
+		return workflowStep
+	}
+
+	jobName := gitlabci.DefaultJobName
+
+	if step.GHAction.ParallelJob.Name != "" {
+		jobName = step.GHAction.ParallelJob.Name
+
+		output.AddStepInParallelJob(jobName, step.GHAction.ParallelJob.NeedsOverride)
+	}
+
+	if !step.GHAction.CronOnly {
+		workflowStep := makeStep(step.GHAction.Environment)
+
+		if err := workflowStep.SetConditions(step.GHAction.Condition); err != nil {
+			return err
+		}
+
+		output.AddStep(jobName, workflowStep)
+	}
+
+	addArtifacts := func(jobName string, artifacts Artifacts, withDefault bool) {
+		if !artifacts.Enabled {
+			return
+		}
+
+		if withDefault && !artifacts.SkipDefaultArtifacts {
+			output.AddArtifacts(jobName, retention(artifacts.RetentionDays), append([]string{step.meta.ArtifactsPath}, artifacts.ExtraPaths...)...)
+		}
+
+		for _, additional := range artifacts.Additional {
+			output.AddArtifacts(jobName, retention(additional.RetentionDays), additional.Paths...)
+
+			if additional.Always {
+				output.SetArtifactsAlways(jobName)
+			}
+		}
+	}
+
+	addArtifacts(jobName, step.GHAction.Artifacts, true)
+
+	for _, job := range step.GHAction.Jobs {
+		rules := xslices.Map(job.TriggerLabels, func(label string) gitlabci.Rule {
+			return gitlabci.Rule{
+				If: fmt.Sprintf(`$CI_MERGE_REQUEST_LABELS =~ /(^|,)%s(,|$)/`, strings.ReplaceAll(regexp.QuoteMeta(label), "/", `\/`)),
+			}
+		})
+
+		needs := job.NeedsOverride
+		if len(needs) == 0 {
+			needs = []string{gitlabci.DefaultJobName}
+		}
+
+		gitlabJob := &gitlabci.Job{
+			Stage: gitlabci.StageTest,
+			Needs: gitlabci.NeedJobs(needs...),
+			Rules: rules,
+		}
+
+		if !step.GHAction.Artifacts.Enabled || job.Artifacts.SkipArtifactDownload {
+			for i := range gitlabJob.Needs {
+				gitlabJob.Needs[i].SkipArtifact = true
+			}
+		}
+
+		if err := gitlabJob.SetConditions(job.Condition); err != nil {
+			return err
+		}
+
+		output.AddJob(job.Name, gitlabJob)
+		output.AddStep(job.Name, makeStep(step.GHAction.Environment, job.EnvironmentOverride))
+
+		addArtifacts(job.Name, step.GHAction.Artifacts, false)
+		addArtifacts(job.Name, job.Artifacts, false)
+
+		if len(job.Crons) > 0 {
+			cronJobName := job.Name + "-cron"
+
+			output.AddScheduledJob(cronJobName, job.Crons, &gitlabci.Job{
+				Stage: gitlabci.StageBuild,
+			})
+			output.AddStep(cronJobName, makeStep(step.GHAction.Environment))
+
+			addArtifacts(cronJobName, step.GHAction.Artifacts, false)
+			addArtifacts(cronJobName, job.Artifacts, false)
+		}
+	}
+
+	return nil
+}
+
+// retention converts artifacts retention days to GitLab CI expire_in value.
+func retention(days string) string {
+	if days == "" {
+		return ""
+	}
+
+	return days + " days"
+}
+
 // legacySignImagesStepName is the name repositories used for their hand-rolled image signing step
 // before kres generated a signing target per image.
 const legacySignImagesStepName = "sign-images"
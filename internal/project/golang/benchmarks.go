@@ -0,0 +1,333 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/dag"
+	"github.com/siderolabs/kres/internal/output/dockerfile"
+	"github.com/siderolabs/kres/internal/output/dockerfile/step"
+	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
+	"github.com/siderolabs/kres/internal/output/makefile"
+	"github.com/siderolabs/kres/internal/project/meta"
+)
+
+const (
+	// benchRegressionsScript picks the benchmarks from the benchstat CSV output which got slower than the threshold.
+	benchRegressionsScript = `awk -F, -v threshold="${BENCHTHRESHOLD}" '` +
+		`$3 == "CI" { unit = $2; next } ` +
+		`unit == "sec/op" && $1 != "geomean" && $6 ~ /^\+[0-9.]+%$/ && substr($6, 2, length($6) - 2) + 0 > threshold + 0 { print $1 ": " $6 }` +
+		`' benchstat.csv > regressions.txt`
+
+	// benchBaselineRetrieveScript looks up the latest workflow run of the main branch which uploaded the benchmark baseline.
+	benchBaselineRetrieveScript = `
+const resp = await github.rest.actions.listArtifactsForRepo({
+    owner: context.repo.owner,
+    repo: context.repo.repo,
+    name: "%s",
+    per_page: 100,
+})
+
+const artifact = resp.data.artifacts.find(artifact => !artifact.expired && artifact.workflow_run.head_branch == "%s")
+
+return artifact ? artifact.workflow_run.id : ""
+`
+
+	// benchCommentScript comments on the pull request with the benchmark comparison.
+	benchCommentScript = `
+const fs = require("fs")
+
+const benchstat = fs.readFileSync("%s", "utf8")
+const regressions = fs.readFileSync("%s", "utf8")
+
+await github.rest.issues.createComment({
+    issue_number: context.issue.number,
+    owner: context.repo.owner,
+    repo: context.repo.repo,
+    body: "Benchmarks regressed by more than %s%%:\n` + "```" + `\n" + regressions + "` + "```" + `\n\n<details><summary>benchstat</summary>\n\n` + "```" + `\n" + benchstat + "` + "```" + `\n</details>",
+})
+`
+)
+
+// Benchmarks runs Go benchmarks and compares the results with the baseline.
+//
+// The baseline for `bench-compare` is read from `$(ARTIFACTS)/bench-baseline/bench.txt`
+// (`bench-<module>-baseline` for the modules in subdirectories).
+type Benchmarks struct {
+	dag.BaseNode
+
+	// Bench is the regular expression selecting the benchmarks to run, passed to `go test -bench`.
+	Bench string `yaml:"bench"`
+	// BenchTime is the duration or the number of iterations of each benchmark, passed to `go test -benchtime`.
+	BenchTime string `yaml:"benchTime"`
+	// Compare configures the CI job comparing the results of the pull requests with the main branch.
+	Compare struct {
+		// Enabled enables the job: the results of the main branch are uploaded as the baseline,
+		// the results of the pull requests are compared with it.
+		Enabled bool `yaml:"enabled"`
+		// CommentOnly comments on the pull request instead of failing the job if the benchmarks regressed,
+		// it is only supported with GitHub Actions.
+		CommentOnly bool `yaml:"commentOnly"`
+	} `yaml:"compare"`
+	// Count is the number of runs of each benchmark, passed to `go test -count`.
+	Count int `yaml:"count"`
+	// Threshold is the slowdown of a benchmark in percent which is reported as a regression.
+	Threshold float64 `yaml:"threshold"`
+	// BenchstatVersion is the version of benchstat used to compare the results.
+	BenchstatVersion string `yaml:"benchstatVersion"`
+
+	meta        *meta.Options
+	packagePath string
+}
+
+// NewBenchmarks initializes Benchmarks.
+func NewBenchmarks(meta *meta.Options, packagePath string) *Benchmarks {
+	meta.BuildArgs.Add("BENCHCOUNT", "BENCHTIME", "BENCHTHRESHOLD", "BENCHSTAT_VERSION")
+
+	return &Benchmarks{
+		BaseNode:         dag.NewBaseNode(genName("bench", packagePath)),
+		Bench:            ".",
+		BenchTime:        "1s",
+		Count:            6,
+		Threshold:        10,
+		BenchstatVersion: config.BenchstatVersion,
+
+		meta:        meta,
+		packagePath: packagePath,
+	}
+}
+
+// baselineArtifact returns the name of the baseline artifact, which is also the name of the build context passing it.
+func (bench *Benchmarks) baselineArtifact() string {
+	return bench.Name() + "-baseline"
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (bench *Benchmarks) CompileDockerfile(output *dockerfile.Output) error {
+	benchRun := bench.Name() + "-run"
+
+	output.Stage(benchRun).
+		Description("runs benchmarks").
+		From("base").
+		Step(step.WorkDir(filepath.Join("/src", bench.packagePath))).
+		Step(step.Arg("TESTPKGS")).
+		Step(step.Arg("BENCHCOUNT")).
+		Step(step.Arg("BENCHTIME")).
+		Step(
+			step.Script(
+				fmt.Sprintf(
+					`go test -run='^$' -bench='%s' -benchmem -count=${BENCHCOUNT} -benchtime=${BENCHTIME} ${TESTPKGS} > bench.txt || (cat bench.txt; exit 1)`,
+					bench.Bench,
+				),
+			).
+				MountCache(filepath.Join(bench.meta.CachePath, "go-build"), bench.meta.GitHubRepository).
+				MountCache(filepath.Join(bench.meta.GoPath, "pkg"), bench.meta.GitHubRepository).
+				MountCache("/tmp", bench.meta.GitHubRepository),
+		)
+
+	output.Stage(bench.Name()).
+		From("scratch").
+		Step(step.Copy(filepath.Join("/src", bench.packagePath, "bench.txt"), "/bench.txt").From(benchRun))
+
+	// the empty stage is overridden with the baseline passed as the named build context
+	output.Stage(bench.baselineArtifact()).
+		Description("benchmark baseline, set with --build-context").
+		From("scratch")
+
+	compareRun := bench.Name() + "-compare-run"
+
+	output.Stage(compareRun).
+		Description("compares benchmarks with the baseline").
+		From("base").
+		Step(step.Arg("BENCHSTAT_VERSION")).
+		Step(
+			step.Script(fmt.Sprintf(
+				`go install golang.org/x/perf/cmd/benchstat@${BENCHSTAT_VERSION} \
+	&& mv /go/bin/benchstat %s/benchstat`, bench.meta.BinPath,
+			)).
+				MountCache(filepath.Join(bench.meta.CachePath, "go-build"), bench.meta.GitHubRepository).
+				MountCache(filepath.Join(bench.meta.GoPath, "pkg"), bench.meta.GitHubRepository),
+		).
+		Step(step.WorkDir("/bench")).
+		Step(step.Copy("/bench.txt", "new.txt").From(bench.Name())).
+		Step(step.Copy("/bench.txt", "base.txt").From(bench.baselineArtifact())).
+		Step(step.Arg("BENCHTHRESHOLD")).
+		Step(step.Script(
+			"benchstat base.txt new.txt > benchstat.txt \\\n" +
+				"\t&& benchstat -format csv base.txt new.txt > benchstat.csv \\\n" +
+				"\t&& " + benchRegressionsScript,
+		))
+
+	output.Stage(bench.Name() + "-compare").
+		From("scratch").
+		Step(step.Copy("/bench/benchstat.txt", "/benchstat.txt").From(compareRun)).
+		Step(step.Copy("/bench/regressions.txt", "/bench-regressions.txt").From(compareRun))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (bench *Benchmarks) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("BENCHCOUNT", strconv.Itoa(bench.Count))).
+		Variable(makefile.OverridableVariable("BENCHTIME", bench.BenchTime)).
+		Variable(makefile.OverridableVariable("BENCHTHRESHOLD", strconv.FormatFloat(bench.Threshold, 'f', -1, 64))).
+		Variable(makefile.OverridableVariable("BENCHSTAT_VERSION", bench.BenchstatVersion))
+
+	output.Target(bench.Name()).
+		Description("Runs benchmarks, the results are exported to $(ARTIFACTS)/bench.txt.").
+		Script("@$(MAKE) local-$@ DEST=$(ARTIFACTS)").
+		Phony()
+
+	output.Target(bench.Name()+"-compare").
+		Description("Compares benchmarks with the baseline in $(ARTIFACTS)/"+bench.baselineArtifact()+"/bench.txt.").
+		Script(
+			`@$(MAKE) local-$@ DEST=$(ARTIFACTS) TARGET_ARGS="--build-context=`+bench.baselineArtifact()+`=$(ARTIFACTS)/`+bench.baselineArtifact()+`"`,
+			"@cat $(ARTIFACTS)/benchstat.txt",
+			`@test ! -s $(ARTIFACTS)/bench-regressions.txt || (echo "Benchmarks regressed by more than $(BENCHTHRESHOLD)%:"; cat $(ARTIFACTS)/bench-regressions.txt; exit 1)`,
+		).
+		Phony()
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (bench *Benchmarks) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	if !bench.Compare.Enabled {
+		return nil
+	}
+
+	baselinePath := filepath.Join(bench.meta.ArtifactsPath, bench.baselineArtifact())
+	hasBaseline := "github.event_name == 'pull_request' && steps.bench-baseline.outputs.result != ''"
+
+	steps := []*ghworkflow.JobStep{
+		ghworkflow.Step(bench.Name()).SetMakeStep(bench.Name()),
+		ghworkflow.Step("save-"+bench.baselineArtifact()).
+			SetUsesWithComment(
+				"actions/upload-artifact@"+config.UploadArtifactActionRef,
+				"version: "+config.UploadArtifactActionVersion,
+			).
+			SetWith("name", bench.baselineArtifact()).
+			SetWith("path", filepath.Join(bench.meta.ArtifactsPath, "bench.txt")).
+			SetWith("retention-days", "90").
+			SetConditionOnlyOnBranch(bench.meta.MainBranch),
+		ghworkflow.Step("Retrieve benchmark baseline").
+			SetID("bench-baseline").
+			SetUsesWithComment(
+				"actions/github-script@"+config.GitHubScriptActionRef,
+				"version: "+config.GitHubScriptActionVersion,
+			).
+			SetWith("result-encoding", "string").
+			SetWith("script", strings.TrimPrefix(fmt.Sprintf(benchBaselineRetrieveScript, bench.baselineArtifact(), bench.meta.MainBranch), "\n")).
+			SetCustomCondition("github.event_name == 'pull_request'"),
+		ghworkflow.Step("Download benchmark baseline").
+			SetUsesWithComment(
+				"actions/download-artifact@"+config.DownloadArtifactActionRef,
+				"version: "+config.DownloadArtifactActionVersion,
+			).
+			SetWith("name", bench.baselineArtifact()).
+			SetWith("path", baselinePath).
+			SetWith("run-id", "${{ steps.bench-baseline.outputs.result }}").
+			SetWith("github-token", "${{ secrets.GITHUB_TOKEN }}").
+			SetCustomCondition(hasBaseline),
+	}
+
+	compare := ghworkflow.Step(bench.Name() + "-compare").
+		SetID("bench-compare").
+		SetMakeStep(bench.Name() + "-compare").
+		SetCustomCondition(hasBaseline)
+
+	steps = append(steps, compare)
+
+	permissions := ghworkflow.Permissions{
+		"actions":       ghworkflow.PermissionActionRead,
+		"contents":      ghworkflow.PermissionActionRead,
+		"pull-requests": ghworkflow.PermissionActionRead,
+	}
+
+	if bench.Compare.CommentOnly {
+		compare.SetContinueOnError()
+
+		permissions["pull-requests"] = ghworkflow.PermissionActionWrite
+
+		steps = append(steps,
+			ghworkflow.Step("Comment benchmark regressions").
+				SetUsesWithComment(
+					"actions/github-script@"+config.GitHubScriptActionRef,
+					"version: "+config.GitHubScriptActionVersion,
+				).
+				SetWith("script", strings.TrimPrefix(fmt.Sprintf(
+					benchCommentScript,
+					filepath.Join(bench.meta.ArtifactsPath, "benchstat.txt"),
+					filepath.Join(bench.meta.ArtifactsPath, "bench-regressions.txt"),
+					strconv.FormatFloat(bench.Threshold, 'f', -1, 64),
+				), "\n")).
+				SetCustomCondition(hasBaseline+" && steps.bench-compare.outcome == 'failure'"),
+		)
+	}
+
+	output.AddJob(bench.Name(), false, &ghworkflow.Job{
+		Permissions: permissions,
+		RunsOn:      ghworkflow.NewRunsOnGroupLabel(ghworkflow.GenericRunner, ""),
+		Needs:       []string{ghworkflow.DefaultJobName},
+		Steps:       append(ghworkflow.DefaultSteps(), steps...),
+	}, nil)
+
+	return nil
+}
+
+// CompileGitLabCI implements gitlabci.Compiler.
+func (bench *Benchmarks) CompileGitLabCI(output *gitlabci.Output) error {
+	if !bench.Compare.Enabled {
+		return nil
+	}
+
+	if bench.Compare.CommentOnly {
+		// commenting on the merge requests needs an API token, the job token can't create notes
+		return fmt.Errorf("%s: compare.commentOnly is not supported with %s CI provider", bench.Name(), meta.CIProviderGitLab)
+	}
+
+	results := filepath.Join(bench.meta.ArtifactsPath, "bench.txt")
+	baselinePath := filepath.Join(bench.meta.ArtifactsPath, bench.baselineArtifact())
+
+	job := &gitlabci.Job{
+		Stage: gitlabci.StageTest,
+		Needs: gitlabci.NeedJobs(gitlabci.DefaultJobName),
+	}
+
+	// the results of the main branch are kept as the baseline, the merge requests compare with it
+	for _, condition := range []string{"on-pull-request", "only-on-main-branch"} {
+		if err := job.SetConditions(condition); err != nil {
+			return err
+		}
+	}
+
+	download := gitlabci.Command(fmt.Sprintf(
+		`mkdir -p %[1]s && wget -qO /tmp/%[2]s.zip --header "JOB-TOKEN: ${CI_JOB_TOKEN}" "${CI_API_V4_URL}/projects/${CI_PROJECT_ID}/jobs/artifacts/${%[3]s}/download?job=%[4]s" && unzip -p /tmp/%[2]s.zip %[5]s > %[1]s/bench.txt || rm -rf %[1]s`,
+		baselinePath, bench.baselineArtifact(), gitlabci.MainBranchVariable, bench.Name(), results,
+	))
+
+	if err := download.SetConditions("on-pull-request"); err != nil {
+		return err
+	}
+
+	compare := gitlabci.Command(fmt.Sprintf("test ! -f %s/bench.txt || make %s-compare", baselinePath, bench.Name()))
+
+	if err := compare.SetConditions("on-pull-request"); err != nil {
+		return err
+	}
+
+	output.AddJob(bench.Name(), job)
+	output.AddStep(bench.Name(), gitlabci.MakeStep(bench.Name()), download, compare)
+	output.AddArtifacts(bench.Name(), "90 days", results)
+
+	return nil
+}
@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/output/dockerfile"
+	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/makefile"
+	"github.com/siderolabs/kres/internal/project/golang"
+	"github.com/siderolabs/kres/internal/project/meta"
+)
+
+func TestBenchmarksInterfaces(t *testing.T) {
+	assert.Implements(t, (*dockerfile.Compiler)(nil), new(golang.Benchmarks))
+	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.Benchmarks))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(golang.Benchmarks))
+}
+
+func TestBenchmarks(t *testing.T) {
+	options := &meta.Options{
+		BinPath:   "/bin",
+		CachePath: "/root/.cache",
+		GoPath:    "/go",
+	}
+
+	bench := golang.NewBenchmarks(options, ".")
+	bench.Threshold = 5.5
+
+	output := dockerfile.NewOutput()
+	output.Stage("base").From("scratch")
+
+	require.NoError(t, bench.CompileDockerfile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Dockerfile", &buf))
+
+	generated := buf.String()
+
+	assert.Contains(t, generated, "go test -run='^$' -bench='.' -benchmem -count=${BENCHCOUNT} -benchtime=${BENCHTIME} ${TESTPKGS} > bench.txt")
+	assert.Contains(t, generated, "FROM scratch AS bench\nCOPY --from=bench-run /src/bench.txt /bench.txt\n")
+	assert.Contains(t, generated, "COPY --from=bench /bench.txt new.txt\nCOPY --from=bench-baseline /bench.txt base.txt\n")
+	assert.Contains(t, generated, "go install golang.org/x/perf/cmd/benchstat@${BENCHSTAT_VERSION}")
+	assert.Contains(t, generated, "benchstat base.txt new.txt > benchstat.txt")
+	assert.Contains(t, options.BuildArgs, "BENCHTHRESHOLD")
+
+	makefileOutput := makefile.NewOutput()
+
+	require.NoError(t, bench.CompileMakefile(makefileOutput))
+
+	buf.Reset()
+
+	require.NoError(t, makefileOutput.GenerateFile("Makefile", &buf))
+	assert.Contains(t, buf.String(), "BENCHCOUNT ?= 6\n")
+	assert.Contains(t, buf.String(), "BENCHTHRESHOLD ?= 5.5\n")
+	assert.Contains(t, buf.String(), "BENCHSTAT_VERSION ?= "+config.BenchstatVersion+"\n")
+	assert.Contains(t, buf.String(), `@$(MAKE) local-$@ DEST=$(ARTIFACTS) TARGET_ARGS="--build-context=bench-baseline=$(ARTIFACTS)/bench-baseline"`)
+}
+
+func TestBenchmarksModule(t *testing.T) {
+	options := &meta.Options{
+		ArtifactsPath: "_out",
+		MainBranch:    "main",
+	}
+
+	bench := golang.NewBenchmarks(options, "tools")
+	bench.Compare.Enabled = true
+
+	workflow := ghworkflow.NewOutput("main", true, false, "")
+	workflow.SetRunnerGroup(ghworkflow.GenericRunner)
+
+	require.NoError(t, bench.CompileGitHubWorkflow(workflow))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, workflow.GenerateFile(ghworkflow.CiWorkflow, &buf))
+
+	// the baseline artifact is uploaded by the job of each module, so its name must be unique within the run
+	assert.Contains(t, buf.String(), "name: bench-tools-baseline\n")
+	assert.Contains(t, buf.String(), "path: _out/bench-tools-baseline\n")
+	assert.NotContains(t, buf.String(), "name: bench-baseline\n")
+}
@@ -12,11 +12,13 @@ import (
 	"strings"
 
 	"github.com/siderolabs/gen/maps"
+	"github.com/siderolabs/gen/xslices"
 
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/output/dockerfile"
 	"github.com/siderolabs/kres/internal/output/dockerfile/step"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -162,6 +164,13 @@ func (build *Build) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 	return nil
 }
 
+// CompileGitLabCI implements gitlabci.Compiler.
+func (build *Build) CompileGitLabCI(output *gitlabci.Output) error {
+	output.AddStep(gitlabci.DefaultJobName, gitlabci.MakeStep(build.Name()))
+
+	return nil
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (build *Build) CompileMakefile(output *makefile.Output) error {
 	artifacts := build.getArtifacts()
@@ -200,13 +209,30 @@ func (build *Build) getArtifacts() []artifact {
 
 	build.configs = []CompileConfig{}
 
-	if len(build.Outputs) == 0 {
+	switch {
+	case len(build.Outputs) == 0 && len(build.meta.Platforms) > 0:
+		build.artifacts = xslices.Map(build.meta.Platforms, func(platform string) artifact {
+			goos, goarch, _ := strings.Cut(platform, "/")
+
+			return artifact{
+				name: strings.Join([]string{build.Name(), goos, goarch}, "-"),
+				config: CompileConfig{
+					"GOOS":   goos,
+					"GOARCH": goarch,
+				},
+			}
+		})
+
+		slices.SortFunc(build.artifacts, func(a, b artifact) int {
+			return cmp.Compare(a.name, b.name)
+		})
+	case len(build.Outputs) == 0:
 		build.artifacts = []artifact{
 			{
 				name: build.Name() + "-linux-amd64",
 			},
 		}
-	} else {
+	default:
 		build.artifacts = maps.ToSlice(build.Outputs, func(name string, config CompileConfig) artifact {
 			return artifact{
 				name:   strings.Join([]string{build.Name(), name}, "-"),
@@ -5,14 +5,17 @@
 package golang_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/siderolabs/kres/internal/output/dockerfile"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/golang"
+	"github.com/siderolabs/kres/internal/project/meta"
 )
 
 func TestBuildInterfaces(t *testing.T) {
@@ -20,3 +23,38 @@ func TestBuildInterfaces(t *testing.T) {
 	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.Build))
 	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(golang.Build))
 }
+
+func TestBuildPlatforms(t *testing.T) {
+	options := &meta.Options{}
+
+	toolchain := golang.NewToolchain(options)
+	toolchain.Platforms = []string{"linux/amd64", "linux/arm64", "darwin/arm64"}
+	require.NoError(t, toolchain.AfterLoad())
+
+	build := golang.NewBuild(options, "kres", "cmd/kres", "go build")
+
+	output := dockerfile.NewOutput()
+	output.Stage("base").From("scratch")
+	output.Stage("generate").From("scratch")
+
+	require.NoError(t, build.CompileDockerfile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Dockerfile", &buf))
+
+	generated := buf.String()
+
+	assert.Contains(t, generated, "AS kres-darwin-arm64-build")
+	assert.Contains(t, generated, "AS kres-linux-amd64-build")
+	assert.Contains(t, generated, "AS kres-linux-arm64-build")
+	assert.Contains(t, generated, "GOARCH=arm64 GOOS=darwin go build")
+	assert.Contains(t, generated, "COPY --from=kres-darwin-arm64 / /")
+}
+
+func TestToolchainInvalidPlatform(t *testing.T) {
+	toolchain := golang.NewToolchain(&meta.Options{})
+	toolchain.Platforms = []string{"linux/arm/v7"}
+
+	assert.EqualError(t, toolchain.AfterLoad(), `invalid platform "linux/arm/v7", expected GOOS/GOARCH`)
+}
@@ -0,0 +1,200 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/siderolabs/gen/xslices"
+
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/dag"
+	"github.com/siderolabs/kres/internal/output/dockerfile"
+	"github.com/siderolabs/kres/internal/output/dockerfile/step"
+	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
+	"github.com/siderolabs/kres/internal/output/makefile"
+	"github.com/siderolabs/kres/internal/project/meta"
+)
+
+// Fuzz runs Go fuzz tests.
+//
+// Each fuzz target is run for the fuzz time, the generated corpus is kept in a dedicated cache mount.
+// The failing inputs written to `testdata/fuzz` are exported to `$(ARTIFACTS)/fuzz`.
+type Fuzz struct {
+	dag.BaseNode
+
+	// FuzzTime is the duration each fuzz target runs for, passed to `go test -fuzztime`.
+	FuzzTime string `yaml:"fuzzTime"`
+	// Targets are the fuzz targets to run, defaults to the `Fuzz*` functions found in the test files.
+	Targets []FuzzTarget `yaml:"targets"`
+	// Crons are the schedules of the GitHub Actions workflow running the fuzz targets, the workflow is generated only if set.
+	Crons []string `yaml:"crons"`
+
+	meta        *meta.Options
+	packagePath string
+}
+
+// FuzzTarget is a fuzz test function in a package.
+type FuzzTarget struct {
+	// Package is the package path relative to the Go module root, e.g. `./pkg/parser`.
+	Package string `yaml:"package"`
+	// Name is the name of the fuzz test function, e.g. `FuzzParse`.
+	Name string `yaml:"name"`
+}
+
+// NewFuzz initializes Fuzz.
+func NewFuzz(meta *meta.Options, packagePath string, targets []FuzzTarget) *Fuzz {
+	return &Fuzz{
+		BaseNode: dag.NewBaseNode(genName("fuzz", packagePath)),
+		FuzzTime: "1m",
+		Targets:  targets,
+
+		meta:        meta,
+		packagePath: packagePath,
+	}
+}
+
+// AfterLoad adds the fuzz time to the build args.
+//
+// The node might be created just to check for its config, so the build args are added only once it is a part of the project.
+func (fuzz *Fuzz) AfterLoad() error {
+	fuzz.meta.BuildArgs.Add("FUZZTIME")
+
+	return nil
+}
+
+// CompileDockerfile implements dockerfile.Compiler.
+func (fuzz *Fuzz) CompileDockerfile(output *dockerfile.Output) error {
+	// -fuzz accepts a single package and a single target, so each target is run separately;
+	// the failures are recorded so that the crashers of all targets are exported
+	lines := []string{"mkdir -p /fuzz", "touch /fuzz-start"}
+
+	for _, target := range fuzz.Targets {
+		lines = append(lines,
+			fmt.Sprintf(`{ go test -run='^$' -fuzz='^%s$' -fuzztime=${FUZZTIME} %s || touch /fuzz/failed; }`, target.Name, target.Package),
+		)
+	}
+
+	lines = append(lines,
+		`find . -path '*/testdata/fuzz/*' -type f -newer /fuzz-start | while read -r crasher; do mkdir -p "/fuzz/$(dirname "${crasher}")" && cp "${crasher}" "/fuzz/${crasher}"; done`,
+	)
+
+	fuzzRun := fuzz.Name() + "-run"
+
+	output.Stage(fuzzRun).
+		Description("runs fuzz tests").
+		From("base").
+		Step(step.WorkDir(filepath.Join("/src", fuzz.packagePath))).
+		Step(step.Arg("FUZZTIME")).
+		Step(
+			step.Script(strings.Join(lines, " \\\n\t&& ")).
+				MountCache(filepath.Join(fuzz.meta.CachePath, "go-build"), fuzz.meta.GitHubRepository).
+				MountCache(filepath.Join(fuzz.meta.CachePath, "go-build", "fuzz"), fuzz.meta.GitHubRepository).
+				MountCache(filepath.Join(fuzz.meta.GoPath, "pkg"), fuzz.meta.GitHubRepository).
+				MountCache("/tmp", fuzz.meta.GitHubRepository),
+		)
+
+	output.Stage(fuzz.Name()).
+		From("scratch").
+		Step(step.Copy("/fuzz", "/").From(fuzzRun))
+
+	return nil
+}
+
+// CompileMakefile implements makefile.Compiler.
+func (fuzz *Fuzz) CompileMakefile(output *makefile.Output) error {
+	output.VariableGroup(makefile.VariableGroupCommon).
+		Variable(makefile.OverridableVariable("FUZZTIME", fuzz.FuzzTime))
+
+	output.Target(fuzz.Name()).
+		Description("Runs fuzz tests, failing inputs are exported to $(ARTIFACTS)/fuzz.").
+		Script(
+			"@$(MAKE) local-$@ DEST=$(ARTIFACTS)/fuzz",
+			"@test ! -f $(ARTIFACTS)/fuzz/failed",
+		).
+		Phony()
+
+	return nil
+}
+
+// CompileGitHubWorkflow implements ghworkflow.Compiler.
+func (fuzz *Fuzz) CompileGitHubWorkflow(output *ghworkflow.Output) error {
+	if len(fuzz.Crons) == 0 {
+		return nil
+	}
+
+	workflowName := fuzz.Name() + "-cron"
+
+	output.AddSlackNotify(workflowName)
+	output.AddSlackNotifyForFailure(workflowName)
+
+	saveCrashers := ghworkflow.Step("save-fuzz-artifacts").
+		SetUsesWithComment(
+			"actions/upload-artifact@"+config.UploadArtifactActionRef,
+			"version: "+config.UploadArtifactActionVersion,
+		).
+		SetWith("name", fuzz.Name()).
+		SetWith("path", filepath.Join(fuzz.meta.ArtifactsPath, "fuzz")).
+		SetWith("retention-days", "5")
+
+	if err := saveCrashers.SetConditions("raw:failure()"); err != nil {
+		return err
+	}
+
+	output.AddWorkflow(
+		workflowName,
+		&ghworkflow.Workflow{
+			Name: workflowName,
+			// https://docs.github.com/en/actions/using-workflows/workflow-syntax-for-github-actions#example-using-a-fallback-value
+			Concurrency: ghworkflow.Concurrency{
+				Group:            "${{ github.head_ref || github.run_id }}",
+				CancelInProgress: true,
+			},
+			On: ghworkflow.On{
+				Schedule: xslices.Map(fuzz.Crons, func(cron string) ghworkflow.Schedule {
+					return ghworkflow.Schedule{
+						Cron: cron,
+					}
+				}),
+			},
+			Jobs: map[string]*ghworkflow.Job{
+				ghworkflow.DefaultJobName: {
+					RunsOn: ghworkflow.NewRunsOnGroupLabel(ghworkflow.GenericRunner, ""),
+					Steps: append(
+						ghworkflow.DefaultSteps(),
+						ghworkflow.Step(fuzz.Name()).SetMakeStep(fuzz.Name()),
+						saveCrashers,
+					),
+				},
+			},
+		},
+	)
+
+	return nil
+}
+
+// CompileGitLabCI implements gitlabci.Compiler.
+func (fuzz *Fuzz) CompileGitLabCI(output *gitlabci.Output) error {
+	if len(fuzz.Crons) == 0 {
+		return nil
+	}
+
+	jobName := fuzz.Name() + "-cron"
+
+	output.AddScheduledJob(jobName, fuzz.Crons, &gitlabci.Job{
+		Stage: gitlabci.StageBuild,
+		Artifacts: &gitlabci.Artifacts{
+			When:     "on_failure",
+			ExpireIn: "5 days",
+			Paths:    []string{filepath.Join(fuzz.meta.ArtifactsPath, "fuzz")},
+		},
+	})
+	output.AddStep(jobName, gitlabci.MakeStep(fuzz.Name()))
+
+	return nil
+}
@@ -0,0 +1,67 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/internal/output/dockerfile"
+	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/makefile"
+	"github.com/siderolabs/kres/internal/project/golang"
+	"github.com/siderolabs/kres/internal/project/meta"
+)
+
+func TestFuzzInterfaces(t *testing.T) {
+	assert.Implements(t, (*dockerfile.Compiler)(nil), new(golang.Fuzz))
+	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.Fuzz))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(golang.Fuzz))
+}
+
+func TestFuzz(t *testing.T) {
+	options := &meta.Options{
+		CachePath: "/root/.cache",
+		GoPath:    "/go",
+	}
+
+	fuzz := golang.NewFuzz(options, ".", []golang.FuzzTarget{
+		{Package: "./pkg/parser", Name: "FuzzParse"},
+		{Package: ".", Name: "FuzzRoot"},
+	})
+
+	require.NoError(t, fuzz.AfterLoad())
+
+	output := dockerfile.NewOutput()
+	output.Stage("base").From("scratch")
+
+	require.NoError(t, fuzz.CompileDockerfile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Dockerfile", &buf))
+
+	generated := buf.String()
+
+	assert.Contains(t, generated, "FROM base AS fuzz-run\nWORKDIR /src\nARG FUZZTIME\n")
+	assert.Contains(t, generated, "--mount=type=cache,target=/root/.cache/go-build/fuzz,id=/root/.cache/go-build/fuzz")
+	assert.Contains(t, generated, "{ go test -run='^$' -fuzz='^FuzzParse$' -fuzztime=${FUZZTIME} ./pkg/parser || touch /fuzz/failed; }")
+	assert.Contains(t, generated, "{ go test -run='^$' -fuzz='^FuzzRoot$' -fuzztime=${FUZZTIME} . || touch /fuzz/failed; }")
+	assert.Contains(t, generated, "FROM scratch AS fuzz\nCOPY --from=fuzz-run /fuzz /\n")
+	assert.Contains(t, options.BuildArgs, "FUZZTIME")
+
+	makefileOutput := makefile.NewOutput()
+
+	require.NoError(t, fuzz.CompileMakefile(makefileOutput))
+
+	buf.Reset()
+
+	require.NoError(t, makefileOutput.GenerateFile("Makefile", &buf))
+	assert.Contains(t, buf.String(), "FUZZTIME ?= 1m\n")
+	assert.Contains(t, buf.String(), "\t@$(MAKE) local-$@ DEST=$(ARTIFACTS)/fuzz\n\t@test ! -f $(ARTIFACTS)/fuzz/failed\n")
+}
@@ -99,6 +99,9 @@ func TestGenerateExtraInputs(t *testing.T) {
 
 	var dockerfileOutput dockerfile.Output
 
+	// stage provided by the toolchain
+	dockerfileOutput.Stage("base").From("scratch")
+
 	require.NoError(t, generate.CompileDockerfile(&dockerfileOutput))
 
 	var dockerfileBuffer bytes.Buffer
@@ -70,6 +70,9 @@ func (lint *GolangciLint) CompileMakefile(output *makefile.Output) error {
 
 // CompileDockerfile implements dockerfile.Compiler.
 func (lint *GolangciLint) CompileDockerfile(output *dockerfile.Output) error {
+	// lint all modules of the workspace at once
+	packages := workspacePackages(lint.meta)
+
 	output.Stage(lint.Name()).
 		Description("runs golangci-lint").
 		From("base").
@@ -77,7 +80,7 @@ func (lint *GolangciLint) CompileDockerfile(output *dockerfile.Output) error {
 		Step(step.Copy(filepath.Join(lint.projectPath, ".golangci.yml"), ".")).
 		Step(step.Env("GOGC", "50")).
 		Step(
-			step.Run("golangci-lint", "run", "--config", ".golangci.yml").
+			step.Run("golangci-lint", append([]string{"run", "--config", ".golangci.yml"}, packages...)...).
 				MountCache(filepath.Join(lint.meta.CachePath, "go-build"), lint.meta.GitHubRepository).
 				MountCache(filepath.Join(lint.meta.CachePath, "golangci-lint"), lint.meta.GitHubRepository, step.CacheLocked).
 				MountCache(filepath.Join(lint.meta.GoPath, "pkg"), lint.meta.GitHubRepository),
@@ -96,7 +99,7 @@ func (lint *GolangciLint) CompileDockerfile(output *dockerfile.Output) error {
 				MountCache(filepath.Join(lint.meta.GoPath, "pkg"), lint.meta.GitHubRepository),
 		).
 		Step(
-			step.Run("golangci-lint", "run", "--fix", "--issues-exit-code", "0", "--config", ".golangci.yml").
+			step.Run("golangci-lint", append([]string{"run", "--fix", "--issues-exit-code", "0", "--config", ".golangci.yml"}, packages...)...).
 				MountCache(filepath.Join(lint.meta.CachePath, "go-build"), lint.meta.GitHubRepository).
 				MountCache(filepath.Join(lint.meta.CachePath, "golangci-lint"), lint.meta.GitHubRepository, step.CacheLocked).
 				MountCache(filepath.Join(lint.meta.GoPath, "pkg"), lint.meta.GitHubRepository),
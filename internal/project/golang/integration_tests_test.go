@@ -0,0 +1,90 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/internal/output/dockerfile"
+	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/makefile"
+	"github.com/siderolabs/kres/internal/project/golang"
+	"github.com/siderolabs/kres/internal/project/meta"
+)
+
+func TestIntegrationTestsInterfaces(t *testing.T) {
+	assert.Implements(t, (*dockerfile.Compiler)(nil), new(golang.IntegrationTests))
+	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.IntegrationTests))
+	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(golang.IntegrationTests))
+}
+
+func TestIntegrationTests(t *testing.T) {
+	options := &meta.Options{
+		ArtifactsPath: "_out",
+		BinPath:       "/bin",
+		CachePath:     "/root/.cache",
+		GoPath:        "/go",
+	}
+
+	test := golang.NewIntegrationTests(options, "integration-test")
+	test.Args = []string{"-test.timeout=30m"}
+	test.Services = map[string]golang.IntegrationTestService{
+		"postgres": {
+			Image:     "postgres:18",
+			Env:       map[string]string{"POSTGRES_PASSWORD": "postgres"},
+			Ports:     []string{"5432:5432"},
+			HealthCmd: "pg_isready -U postgres",
+		},
+	}
+
+	assert.Equal(t, "integration-test-run", test.Name())
+	assert.Equal(t, "coverage-integration-test.txt", test.CoverageFile())
+	assert.Contains(t, options.BuildArgs, "GOTESTSUM_VERSION")
+
+	output := dockerfile.NewOutput()
+	output.Stage("toolchain").From("scratch")
+
+	require.NoError(t, test.CompileDockerfile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Dockerfile", &buf))
+
+	generated := buf.String()
+
+	assert.Contains(t, generated, "FROM scratch AS integration-test-run-log\n")
+	assert.Contains(t, generated, "COPY --from=integration-test-run-log /test.log /test.log\nRUN gotestsum --junitfile /junit.xml --raw-command -- go tool test2json -p integration-test cat /test.log || test -f /junit.xml\n")
+	assert.Contains(t, generated, "FROM scratch AS integration-test-run-junit\nCOPY --from=integration-test-run-junit-run /junit.xml /junit-integration-test.xml\n")
+
+	makefileOutput := makefile.NewOutput()
+
+	require.NoError(t, test.CompileMakefile(makefileOutput))
+
+	buf.Reset()
+
+	require.NoError(t, makefileOutput.GenerateFile("Makefile", &buf))
+	assert.Contains(t, buf.String(), "integration-test-run: integration-test-linux-amd64")
+	assert.Contains(t, buf.String(),
+		"@$(ARTIFACTS)/integration-test-linux-amd64 -test.v -test.coverprofile=$(ARTIFACTS)/coverage-integration-test.txt -test.timeout=30m > ")
+	assert.Contains(t, buf.String(), `TARGET_ARGS="--build-context=integration-test-run-log=$(ARTIFACTS)/integration-test-run-log"`)
+	assert.Contains(t, buf.String(), "exit $$status\n")
+
+	workflow := ghworkflow.NewOutput("main", true, false, "")
+	workflow.SetRunnerGroup(ghworkflow.GenericRunner)
+
+	require.NoError(t, test.CompileGitHubWorkflow(workflow))
+
+	buf.Reset()
+
+	require.NoError(t, workflow.GenerateFile(ghworkflow.CiWorkflow, &buf))
+	assert.Contains(t, buf.String(), "image: postgres:18\n")
+	assert.Contains(t, buf.String(), `--health-cmd "pg_isready -U postgres" --health-interval 10s`)
+	assert.Contains(t, buf.String(), "make integration-test-run\n")
+	assert.Contains(t, buf.String(), "_out/junit-integration-test.xml\n")
+}
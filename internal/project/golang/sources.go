@@ -0,0 +1,42 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+// Sources configures the discovery of Go source directories and commands.
+//
+// By default, `api`, `cmd`, `controllers`, `internal`, `pkg`, `src` and any other top-level directory of a Go module
+// containing Go files (ignoring the files excluded with `//go:build ignore`) are treated as sources,
+// and each directory under `cmd/` is built as a command.
+//
+// Example:
+//
+//	---
+//	kind: golang.Sources
+//	spec:
+//	  include:
+//	    - tools/*
+//	  exclude:
+//	    - hack
+//	  commands:
+//	    - name: kres
+//	      path: .
+//	    - name: gen
+//	      path: tools/gen
+type Sources struct {
+	// Include lists glob patterns of the additional source directories relative to the Go module root, e.g. `tools/*`.
+	Include []string `yaml:"include"`
+	// Exclude lists glob patterns of the directories relative to the Go module root which are not treated as sources.
+	Exclude []string `yaml:"exclude"`
+	// Commands lists the commands to be built, replacing the commands discovered under `cmd/`.
+	Commands []SourcesCommand `yaml:"commands"`
+}
+
+// SourcesCommand defines a command built from the package at the path.
+type SourcesCommand struct {
+	// Name is the name of the command binary.
+	Name string `yaml:"name"`
+	// Path is the package path relative to the project root, e.g. `.` or `tools/gen`.
+	Path string `yaml:"path"`
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/siderolabs/kres/internal/output/dockerfile"
 	"github.com/siderolabs/kres/internal/output/dockerfile/step"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/common"
 	"github.com/siderolabs/kres/internal/project/meta"
@@ -53,6 +54,11 @@ type Toolchain struct { //nolint:govet
 	// - Makefile variable `WITH_$TAG`
 	// - If variable is set, the build tag is passed to go build via `-tags` flag
 	BuildTags []string `yaml:"buildTags"`
+	// Platforms is a list of GOOS/GOARCH pairs (e.g. linux/arm64) every command is built for,
+	// unless outputs are set for the command explicitly.
+	//
+	// Linux platforms are also used as the default platforms of the Docker images.
+	Platforms []string `yaml:"platforms"`
 }
 
 // NewToolchain builds Toolchain with default values.
@@ -90,6 +96,15 @@ func (toolchain *Toolchain) AfterLoad() error {
 		toolchain.meta.BuildArgs = append(toolchain.meta.BuildArgs, "GITHUB_TOKEN")
 	}
 
+	for _, platform := range toolchain.Platforms {
+		goos, goarch, ok := strings.Cut(platform, "/")
+		if !ok || goos == "" || goarch == "" || strings.Contains(goarch, "/") {
+			return fmt.Errorf("invalid platform %q, expected GOOS/GOARCH", platform)
+		}
+	}
+
+	toolchain.meta.Platforms = toolchain.Platforms
+
 	return nil
 }
 
@@ -199,6 +214,13 @@ func (toolchain *Toolchain) CompileGitHubWorkflow(output *ghworkflow.Output) err
 	return nil
 }
 
+// CompileGitLabCI implements gitlabci.Compiler.
+func (toolchain *Toolchain) CompileGitLabCI(output *gitlabci.Output) error {
+	output.AddStep(gitlabci.DefaultJobName, gitlabci.MakeStep("base"))
+
+	return nil
+}
+
 // CompileDockerfile implements dockerfile.Compiler.
 func (toolchain *Toolchain) CompileDockerfile(output *dockerfile.Output) error {
 	output.Arg(step.Arg("TOOLCHAIN=scratch"))
@@ -267,10 +289,25 @@ func (toolchain *Toolchain) CompileDockerfile(output *dockerfile.Output) error {
 			Step(step.Copy(gosumPath, gosumPath))
 	}
 
-	for _, rootDir := range toolchain.meta.GoRootDirectories {
-		base.Step(step.Run("cd", rootDir)).
-			Step(step.Run("go", "mod", "download").MountCache(filepath.Join(toolchain.meta.GoPath, "pkg"), toolchain.meta.GitHubRepository)).
+	for _, file := range toolchain.meta.GoWorkspaceFiles {
+		base.Step(step.Copy(file, file))
+	}
+
+	// replaced modules are resolved from the local directories on download
+	for _, directory := range toolchain.meta.GoReplaceDirectories {
+		base.Step(step.Copy("./"+directory, "./"+directory))
+	}
+
+	if len(toolchain.meta.GoWorkspaceModules) > 0 {
+		// the workspace drives the downloads for all of its modules
+		base.Step(step.Run("go", "mod", "download").MountCache(filepath.Join(toolchain.meta.GoPath, "pkg"), toolchain.meta.GitHubRepository)).
 			Step(step.Run("go", "mod", "verify").MountCache(filepath.Join(toolchain.meta.GoPath, "pkg"), toolchain.meta.GitHubRepository))
+	} else {
+		for _, rootDir := range toolchain.meta.GoRootDirectories {
+			base.Step(step.Run("cd", rootDir)).
+				Step(step.Run("go", "mod", "download").MountCache(filepath.Join(toolchain.meta.GoPath, "pkg"), toolchain.meta.GitHubRepository)).
+				Step(step.Run("go", "mod", "verify").MountCache(filepath.Join(toolchain.meta.GoPath, "pkg"), toolchain.meta.GitHubRepository))
+		}
 	}
 
 	for _, directory := range toolchain.meta.GoDirectories {
@@ -6,13 +6,18 @@ package golang
 
 import (
 	"fmt"
+	"maps"
 	"path/filepath"
 	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/siderolabs/kres/internal/config"
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/output/dockerfile"
 	"github.com/siderolabs/kres/internal/output/dockerfile/step"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -36,29 +41,209 @@ type UnitTests struct { //nolint:govet
 		} `yaml:"steps"`
 	} `yaml:"docker"`
 	RunFIPS bool `yaml:"runFIPS"`
-	Verbose bool `yaml:"verbose"`
-	Count   int  `yaml:"count"`
+	// RunCrossArch runs unit-tests cross-compiled for every linux platform of the toolchain
+	// (except for amd64) under QEMU user mode emulation as unit-tests-<arch>.
+	RunCrossArch bool `yaml:"runCrossArch"`
+	Verbose      bool `yaml:"verbose"`
+	Count        int  `yaml:"count"`
+	// JUnit exports the JUnit XML report of the unit-tests to $(ARTIFACTS)/junit-<name>.xml,
+	// the output of `go test -json` is converted with gotestsum.
+	// The test runs are not cached by the build then, as the failed runs would be cached too, go test still caches the passed packages.
+	JUnit bool `yaml:"junit"`
+	// GoTestSumVersion is the version of gotestsum used to produce the JUnit XML report.
+	GoTestSumVersion string `yaml:"gotestsumVersion"`
+	// Shards splits the packages into the number of shards, each run as unit-tests-shard-<i>.
+	// The packages are distributed over the shards round-robin in the `go list` order,
+	// the shards are run as a GitHub Actions matrix job, and the coverage of the shards is merged.
+	Shards int `yaml:"shards"`
+	// Variants are the extra runs of the unit-tests with different settings, each run as unit-tests-<variant>.
+	// Setting the variants replaces the default race detector variant.
+	Variants []UnitTestsVariant `yaml:"variants"`
 
 	packagePath string
 
 	meta *meta.Options
 }
 
+// UnitTestsVariant is a run of the unit-tests with the race detector, shuffled tests, GOEXPERIMENT, etc.
+type UnitTestsVariant struct {
+	// Name is the name of the variant, the target is unit-tests-<name>.
+	Name string `yaml:"name"`
+	// Race enables the race detector.
+	Race bool `yaml:"race"`
+	// Shuffle is the value of `go test -shuffle`, e.g. `on`.
+	Shuffle string `yaml:"shuffle"`
+	// GoExperiment is the value of GOEXPERIMENT for the tests.
+	GoExperiment string `yaml:"goExperiment"`
+	// Env is the extra environment for the tests.
+	Env map[string]string `yaml:"env"`
+	// ExtraArgs are extra arguments for `go test` of the variant.
+	ExtraArgs string `yaml:"extraArgs"`
+	// Coverage exports the coverage of the variant to $(ARTIFACTS)/coverage-unit-tests-<name>.txt and uploads it with the unit-tests-<name> flag.
+	Coverage bool `yaml:"coverage"`
+	// Job runs the variant as the separate unit-tests-<name> GitHub Actions job instead of the step in the unit-tests job.
+	Job bool `yaml:"job"`
+}
+
+// flags returns the `go test` flags of the variant.
+func (variant *UnitTestsVariant) flags() string {
+	flags := ""
+
+	if variant.Race {
+		flags += "-race "
+	}
+
+	if variant.Shuffle != "" {
+		flags += "-shuffle=" + variant.Shuffle + " "
+	}
+
+	return flags
+}
+
+// extraArgs returns the extra `go test` arguments of the variant.
+func (variant *UnitTestsVariant) extraArgs() string {
+	if variant.ExtraArgs == "" {
+		return ""
+	}
+
+	return variant.ExtraArgs + " "
+}
+
+// environment returns the environment of the variant for the test run as name-value pairs.
+func (variant *UnitTestsVariant) environment() [][2]string {
+	var env [][2]string
+
+	if variant.Race {
+		env = append(env, [2]string{"CGO_ENABLED", "1"})
+	}
+
+	if variant.GoExperiment != "" {
+		env = append(env, [2]string{"GOEXPERIMENT", variant.GoExperiment})
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(variant.Env)) {
+		env = append(env, [2]string{name, variant.Env[name]})
+	}
+
+	return env
+}
+
+// env sets the environment of the variant for the test command.
+func (variant *UnitTestsVariant) env(s *step.RunStep) *step.RunStep {
+	for _, env := range variant.environment() {
+		s.Env(env[0], env[1])
+	}
+
+	return s
+}
+
+// describe returns the description of the variant settings, race is the description of the race detector.
+func (variant *UnitTestsVariant) describe(race string) string {
+	var settings []string
+
+	if variant.Race {
+		settings = append(settings, race)
+	}
+
+	if variant.Shuffle != "" {
+		settings = append(settings, "-shuffle="+variant.Shuffle)
+	}
+
+	if variant.GoExperiment != "" {
+		settings = append(settings, "GOEXPERIMENT="+variant.GoExperiment)
+	}
+
+	if len(settings) == 0 {
+		return variant.Name + " variant"
+	}
+
+	return strings.Join(settings, ", ")
+}
+
+// jobName returns the name of the CI job running the variant.
+func (variant *UnitTestsVariant) jobName() string {
+	if variant.Job {
+		return "unit-tests-" + variant.Name
+	}
+
+	return "unit-tests"
+}
+
 // NewUnitTests initializes UnitTests.
 func NewUnitTests(meta *meta.Options, packagePath string) *UnitTests {
 	meta.BuildArgs.Add("TESTPKGS")
 
-	if !slices.Contains(meta.ExtraEnforcedContexts, "unit-tests") {
-		meta.ExtraEnforcedContexts = append(meta.ExtraEnforcedContexts, "unit-tests")
-	}
-
 	return &UnitTests{
-		BaseNode:    dag.NewBaseNode(genName("unit-tests", packagePath)),
+		BaseNode:         dag.NewBaseNode(genName("unit-tests", packagePath)),
+		GoTestSumVersion: config.GoTestSumVersion,
+		Variants: []UnitTestsVariant{
+			{
+				Name: "race",
+				Race: true,
+			},
+		},
 		meta:        meta,
 		packagePath: packagePath,
 	}
 }
 
+// AfterLoad validates the variants and adds the gotestsum version to the build args if the JUnit report is enabled.
+//
+// The unit-tests job is required by the branch protection only if the node is not dropped from the project.
+func (tests *UnitTests) AfterLoad() error {
+	if !slices.Contains(tests.meta.ExtraEnforcedContexts, "unit-tests") {
+		tests.meta.ExtraEnforcedContexts = append(tests.meta.ExtraEnforcedContexts, "unit-tests")
+	}
+
+	names := map[string]struct{}{}
+
+	for _, variant := range tests.Variants {
+		if variant.Name == "" {
+			return fmt.Errorf("%s: variant name is required", tests.Name())
+		}
+
+		if _, ok := names[variant.Name]; ok {
+			return fmt.Errorf("%s: duplicate variant %q", tests.Name(), variant.Name)
+		}
+
+		names[variant.Name] = struct{}{}
+	}
+
+	if tests.JUnit {
+		tests.meta.BuildArgs.Add("GOTESTSUM_VERSION")
+	}
+
+	return nil
+}
+
+// DiscoverCoverage implements service.CoverageDiscoverer.
+func (tests *UnitTests) DiscoverCoverage(add func(jobName, flags string, inputs ...string)) {
+	add("unit-tests", "unit-tests", fmt.Sprintf("coverage-%s.txt", tests.Name()))
+
+	for _, variant := range tests.Variants {
+		if variant.Coverage {
+			add(variant.jobName(), "unit-tests-"+variant.Name, fmt.Sprintf("coverage-%s.txt", tests.variantName(variant)))
+		}
+	}
+}
+
+func (tests *UnitTests) variantName(variant UnitTestsVariant) string {
+	return tests.Name() + "-" + variant.Name
+}
+
+// shards returns the number of shards, sharding is disabled if there is a single shard.
+func (tests *UnitTests) shards() int {
+	if tests.Shards < 2 {
+		return 0
+	}
+
+	return tests.Shards
+}
+
+func (tests *UnitTests) shardName(shard int) string {
+	return fmt.Sprintf("%s-shard-%d", tests.Name(), shard)
+}
+
 // CompileDockerfile implements dockerfile.Compiler.
 func (tests *UnitTests) CompileDockerfile(output *dockerfile.Output) error {
 	wrapAsInsecure := func(s *step.RunStep) *step.RunStep {
@@ -91,14 +276,13 @@ func (tests *UnitTests) CompileDockerfile(output *dockerfile.Output) error {
 		extraArgs += " "
 	}
 
-	workdir := step.WorkDir(filepath.Join("/src", tests.packagePath))
-	testRun := tests.Name() + "-run"
-
-	testRunStage := output.Stage(testRun).
-		Description("runs unit-tests").
-		From("base")
+	coverPkg := "${TESTPKGS}"
+	if len(tests.meta.GoWorkspaceModules) > 0 {
+		// packages of the workspace are space-separated, while -coverpkg expects a comma-separated list
+		coverPkg = "$(echo ${TESTPKGS} | tr ' ' ,)"
+	}
 
-	applyDockerCopySteps(testRunStage)
+	workdir := step.WorkDir(filepath.Join("/src", tests.packagePath))
 
 	verboseArg := ""
 	if tests.Verbose {
@@ -110,38 +294,135 @@ func (tests *UnitTests) CompileDockerfile(output *dockerfile.Output) error {
 		countArg = fmt.Sprintf("-count %d ", tests.Count)
 	}
 
-	testRunStage.Step(workdir).
-		Step(step.Arg("TESTPKGS")).
-		Step(wrapAsInsecure(
-			step.Script(
-				fmt.Sprintf(`go test %s-covermode=atomic -coverprofile=coverage.txt -coverpkg=${TESTPKGS} %s%s${TESTPKGS}`, verboseArg, countArg, extraArgs),
-			).
+	// addTestRun adds the stage running the tests of pkgs with coverage, wrap (if set) wraps the test command
+	addTestRun := func(name, description, pkgs string, variant *UnitTestsVariant, wrap func(command string) string) {
+		testRun := name + "-run"
+
+		testRunStage := output.Stage(testRun).
+			Description(description).
+			From("base")
+
+		applyDockerCopySteps(testRunStage)
+
+		testRunStage.Step(workdir)
+
+		variantFlags, variantArgs := "", ""
+		if variant != nil {
+			variantFlags, variantArgs = variant.flags(), variant.extraArgs()
+		}
+
+		testCommand := fmt.Sprintf(`go test %s%s-covermode=atomic -coverprofile=coverage.txt -coverpkg=%s %s%s%s%s`,
+			verboseArg, variantFlags, coverPkg, countArg, extraArgs, variantArgs, pkgs)
+
+		if tests.JUnit {
+			testRunStage.
+				Step(step.Arg("GOTESTSUM_VERSION")).
+				Step(
+					step.Script(fmt.Sprintf(
+						`go install gotest.tools/gotestsum@${GOTESTSUM_VERSION} \
+	&& mv /go/bin/gotestsum %s/gotestsum`, tests.meta.BinPath,
+					)).
+						MountCache(filepath.Join(tests.meta.CachePath, "go-build"), tests.meta.GitHubRepository).
+						MountCache(filepath.Join(tests.meta.GoPath, "pkg"), tests.meta.GitHubRepository),
+				)
+
+			format := "standard-quiet"
+			if tests.Verbose {
+				format = "standard-verbose"
+			}
+
+			// the test failure is recorded, so that the reports are exported even if the tests fail
+			testCommand = fmt.Sprintf(
+				`mkdir -p /test-reports \
+	&& gotestsum --format %s --junitfile /test-reports/junit-%s.xml -- %s-covermode=atomic -coverprofile=coverage.txt -coverpkg=%s %s%s%s%s \
+	|| touch coverage.txt /test-reports/%s-failed`,
+				format, name, variantFlags, coverPkg, countArg, extraArgs, variantArgs, pkgs, name,
+			)
+		}
+
+		if wrap != nil {
+			testCommand = wrap(testCommand)
+		}
+
+		testRunStage.Step(step.Arg("TESTPKGS"))
+
+		// the test command might be a script, so the environment of the variant is set for the stage
+		if variant != nil {
+			for _, env := range variant.environment() {
+				testRunStage.Step(step.Env(env[0], env[1]))
+			}
+		}
+
+		testRunStage.Step(wrapAsInsecure(
+			step.Script(testCommand).
 				MountCache(filepath.Join(tests.meta.CachePath, "go-build"), tests.meta.GitHubRepository).
 				MountCache(filepath.Join(tests.meta.GoPath, "pkg"), tests.meta.GitHubRepository).
 				MountCache("/tmp", tests.meta.GitHubRepository),
 		))
+	}
 
-	output.Stage(tests.Name()).
-		From("scratch").
-		Step(step.Copy(filepath.Join("/src", tests.packagePath, "coverage.txt"), fmt.Sprintf("/coverage-%s.txt", tests.Name())).From(testRun))
+	// addTestStage adds the stage exporting the coverage (and the JUnit report) of the test run
+	addTestStage := func(name string) {
+		testStage := output.Stage(name).
+			From("scratch").
+			Step(step.Copy(filepath.Join("/src", tests.packagePath, "coverage.txt"), fmt.Sprintf("/coverage-%s.txt", name)).From(name + "-run"))
 
-	testRunRaceStage := output.Stage(tests.Name() + "-race").
-		Description("runs unit-tests with race detector").
-		From("base")
+		if tests.JUnit {
+			testStage.Step(step.Copy("/test-reports", "/").From(name + "-run"))
+		}
+	}
 
-	applyDockerCopySteps(testRunRaceStage)
+	addTestRun(tests.Name(), "runs unit-tests", "${TESTPKGS}", nil, nil)
+	addTestStage(tests.Name())
 
-	testRunRaceStage.Step(workdir).
-		Step(step.Arg("TESTPKGS")).
-		Step(wrapAsInsecure(
-			step.Script(
-				fmt.Sprintf(`go test %s-race %s%s${TESTPKGS}`, verboseArg, countArg, extraArgs),
-			).
-				MountCache(filepath.Join(tests.meta.CachePath, "go-build"), tests.meta.GitHubRepository).
-				MountCache(filepath.Join(tests.meta.GoPath, "pkg"), tests.meta.GitHubRepository).
-				MountCache("/tmp", tests.meta.GitHubRepository).
-				Env("CGO_ENABLED", "1"),
-		))
+	for shard := 1; shard <= tests.shards(); shard++ {
+		name := tests.shardName(shard)
+
+		// the packages are distributed over the shards round-robin in the `go list` order,
+		// a shard without packages exports the empty coverage profile
+		emptyShard := `echo "mode: atomic" > coverage.txt`
+		if tests.JUnit {
+			emptyShard = "mkdir -p /test-reports && " + emptyShard
+		}
+
+		addTestRun(name, fmt.Sprintf("runs unit-tests shard %d of %d", shard, tests.Shards), "${SHARDPKGS}", nil, func(command string) string {
+			return fmt.Sprintf(
+				`SHARDPKGS="$(go list ${TESTPKGS} | awk -v shard=%d -v shards=%d '(NR - 1) %% shards + 1 == shard')" \
+	&& if [ -z "${SHARDPKGS}" ]; then %s; else %s; fi`,
+				shard, tests.Shards, emptyShard, command,
+			)
+		})
+		addTestStage(name)
+	}
+
+	for _, variant := range tests.Variants {
+		name := tests.variantName(variant)
+		description := "runs unit-tests with " + variant.describe("race detector")
+
+		if variant.Coverage {
+			addTestRun(name, description, "${TESTPKGS}", &variant, nil)
+			addTestStage(name)
+
+			continue
+		}
+
+		testRunVariantStage := output.Stage(name).
+			Description(description).
+			From("base")
+
+		applyDockerCopySteps(testRunVariantStage)
+
+		testRunVariantStage.Step(workdir).
+			Step(step.Arg("TESTPKGS")).
+			Step(wrapAsInsecure(variant.env(
+				step.Script(
+					fmt.Sprintf(`go test %s%s%s%s%s${TESTPKGS}`, verboseArg, variant.flags(), countArg, extraArgs, variant.extraArgs()),
+				).
+					MountCache(filepath.Join(tests.meta.CachePath, "go-build"), tests.meta.GitHubRepository).
+					MountCache(filepath.Join(tests.meta.GoPath, "pkg"), tests.meta.GitHubRepository).
+					MountCache("/tmp", tests.meta.GitHubRepository),
+			)))
+	}
 
 	if tests.RunFIPS {
 		testRunFIPSStage := output.Stage(tests.Name() + "-fips").
@@ -164,13 +445,84 @@ func (tests *UnitTests) CompileDockerfile(output *dockerfile.Output) error {
 			))
 	}
 
+	for _, arch := range tests.crossArchs() {
+		emulator, ok := qemuEmulators[arch]
+		if !ok {
+			return fmt.Errorf("unit-tests under emulation are not supported for %s", arch)
+		}
+
+		emulatorPath := "/usr/bin/" + emulator
+
+		testRunArchStage := output.Stage(tests.Name() + "-" + arch).
+			Description(fmt.Sprintf("runs unit-tests for linux/%s under emulation", arch)).
+			From("base").
+			Step(step.Copy(emulatorPath, emulatorPath).From("docker.io/tonistiigi/binfmt:" + config.BinfmtImageVersion))
+
+		applyDockerCopySteps(testRunArchStage)
+
+		testRunArchStage.Step(workdir).
+			Step(step.Arg("TESTPKGS")).
+			Step(wrapAsInsecure(
+				step.Script(
+					fmt.Sprintf(`go test %s-exec %s %s%s${TESTPKGS}`, verboseArg, emulator, countArg, extraArgs),
+				).
+					MountCache(filepath.Join(tests.meta.CachePath, "go-build"), tests.meta.GitHubRepository).
+					MountCache(filepath.Join(tests.meta.GoPath, "pkg"), tests.meta.GitHubRepository).
+					MountCache("/tmp", tests.meta.GitHubRepository).
+					Env("CGO_ENABLED", "0").
+					Env("GOARCH", arch),
+			))
+	}
+
 	return nil
 }
 
+// coverageMergeScript merges the coverage profiles of the shards summing up the counts of the same blocks.
+const coverageMergeScript = `awk 'FNR == 1 { mode = $0; next } { count[$1 " " $2] += $3 } END { print mode; for (block in count) print block, count[block] }' %s > %s`
+
+// qemuEmulators maps GOARCH to the name of QEMU user mode emulator.
+var qemuEmulators = map[string]string{
+	"386":      "qemu-i386",
+	"amd64":    "qemu-x86_64",
+	"arm":      "qemu-arm",
+	"arm64":    "qemu-aarch64",
+	"loong64":  "qemu-loongarch64",
+	"mips64le": "qemu-mips64el",
+	"ppc64le":  "qemu-ppc64le",
+	"riscv64":  "qemu-riscv64",
+	"s390x":    "qemu-s390x",
+}
+
+// crossArchs returns the list of architectures to run unit-tests under emulation for.
+func (tests *UnitTests) crossArchs() []string {
+	if !tests.RunCrossArch {
+		return nil
+	}
+
+	var archs []string
+
+	for _, platform := range tests.meta.Platforms {
+		goos, goarch, _ := strings.Cut(platform, "/")
+
+		if goos != "linux" || goarch == "amd64" || slices.Contains(archs, goarch) {
+			continue
+		}
+
+		archs = append(archs, goarch)
+	}
+
+	return archs
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (tests *UnitTests) CompileMakefile(output *makefile.Output) error {
+	testPkgs := "./..."
+	if len(tests.meta.GoWorkspaceModules) > 0 {
+		testPkgs = strings.Join(workspacePackages(tests.meta), " ")
+	}
+
 	output.VariableGroup(makefile.VariableGroupCommon).
-		Variable(makefile.OverridableVariable("TESTPKGS", "./..."))
+		Variable(makefile.OverridableVariable("TESTPKGS", testPkgs))
 
 	scriptExtraArgs := ""
 
@@ -178,15 +530,60 @@ func (tests *UnitTests) CompileMakefile(output *makefile.Output) error {
 		scriptExtraArgs += `  TARGET_ARGS="--allow security.insecure"`
 	}
 
+	// the tests export the coverage (and the JUnit report)
+	script := func(name string) []string {
+		if !tests.JUnit {
+			return []string{"@$(MAKE) local-$@ DEST=$(ARTIFACTS)" + scriptExtraArgs}
+		}
+
+		// the reports are exported even if the tests fail, the failure is signaled with the marker file
+		marker := "$(ARTIFACTS)/" + name + "-failed"
+
+		// the failed run is exported successfully, so it's excluded from the build cache not to report the cached failure
+		targetArgs := "--no-cache-filter=" + name + "-run"
+
+		if tests.RequiresInsecure {
+			targetArgs += " --allow security.insecure"
+		}
+
+		return []string{
+			"@rm -f " + marker,
+			fmt.Sprintf(`@$(MAKE) local-$@ DEST=$(ARTIFACTS) TARGET_ARGS="%s"`, targetArgs),
+			"@test ! -f " + marker,
+		}
+	}
+
+	if tests.JUnit {
+		output.VariableGroup(makefile.VariableGroupCommon).
+			Variable(makefile.OverridableVariable("GOTESTSUM_VERSION", tests.GoTestSumVersion))
+	}
+
 	output.Target(tests.Name()).
 		Description("Performs unit tests").
-		Script("@$(MAKE) local-$@ DEST=$(ARTIFACTS)" + scriptExtraArgs).
+		Script(script(tests.Name())...).
 		Phony()
 
-	output.Target(tests.Name() + "-race").
-		Description("Performs unit tests with race detection enabled.").
-		Script("@$(MAKE) target-$@" + scriptExtraArgs).
-		Phony()
+	for shard := 1; shard <= tests.shards(); shard++ {
+		output.Target(tests.shardName(shard)).
+			Description(fmt.Sprintf("Performs unit tests shard %d of %d.", shard, tests.Shards)).
+			Script(script(tests.shardName(shard))...).
+			Phony()
+	}
+
+	for _, variant := range tests.Variants {
+		name := tests.variantName(variant)
+
+		// the variants with coverage are exported, the others are just built
+		variantScript := []string{"@$(MAKE) target-$@" + scriptExtraArgs}
+		if variant.Coverage {
+			variantScript = script(name)
+		}
+
+		output.Target(name).
+			Description(fmt.Sprintf("Performs unit tests with %s.", variant.describe("race detection enabled"))).
+			Script(variantScript...).
+			Phony()
+	}
 
 	if tests.RunFIPS {
 		output.Target(tests.Name() + "-fips").
@@ -195,18 +592,63 @@ func (tests *UnitTests) CompileMakefile(output *makefile.Output) error {
 			Phony()
 	}
 
+	for _, arch := range tests.crossArchs() {
+		output.Target(tests.Name() + "-" + arch).
+			Description(fmt.Sprintf("Performs unit tests for linux/%s under emulation.", arch)).
+			Script("@$(MAKE) target-$@" + scriptExtraArgs).
+			Phony()
+	}
+
 	return nil
 }
 
 // CompileGitHubWorkflow implements ghworkflow.Compiler.
 func (tests *UnitTests) CompileGitHubWorkflow(output *ghworkflow.Output) error {
-	output.AddStepInParallelJob(
-		"unit-tests",
-		ghworkflow.GenericRunner,
-		nil,
-		ghworkflow.Step(tests.Name()).SetMakeStep(tests.Name()),
-		ghworkflow.Step(tests.Name()+"-race").SetMakeStep(tests.Name()+"-race"),
-	)
+	if tests.shards() > 0 {
+		if err := tests.compileShardsGitHubWorkflow(output); err != nil {
+			return err
+		}
+	} else {
+		output.AddStepInParallelJob(
+			"unit-tests",
+			ghworkflow.GenericRunner,
+			nil,
+			ghworkflow.Step(tests.Name()).SetMakeStep(tests.Name()),
+		)
+
+		if tests.JUnit {
+			output.AddStepInParallelJob(
+				"unit-tests",
+				ghworkflow.GenericRunner,
+				nil,
+				ghworkflow.TestReportSteps(tests.Name()+"-junit", filepath.Join(tests.meta.ArtifactsPath, "junit-"+tests.Name()+".xml"))...,
+			)
+		}
+	}
+
+	for _, variant := range tests.Variants {
+		name := tests.variantName(variant)
+		steps := []*ghworkflow.JobStep{ghworkflow.Step(name).SetMakeStep(name)}
+
+		if variant.Coverage && tests.JUnit {
+			steps = append(steps, ghworkflow.TestReportSteps(name+"-junit", filepath.Join(tests.meta.ArtifactsPath, "junit-"+name+".xml"))...)
+		}
+
+		output.AddStepInParallelJob(
+			variant.jobName(),
+			ghworkflow.GenericRunner,
+			nil,
+			steps...,
+		)
+
+		if variant.Job && tests.meta.SOPSEnabled {
+			output.AddStepAfter(
+				variant.jobName(),
+				"setup-buildx",
+				ghworkflow.SOPSSteps()...,
+			)
+		}
+	}
 
 	if tests.meta.SOPSEnabled {
 		output.AddStepAfter(
@@ -233,5 +675,157 @@ func (tests *UnitTests) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 		}
 	}
 
+	for _, arch := range tests.crossArchs() {
+		output.AddStepInParallelJob(
+			"unit-tests",
+			ghworkflow.GenericRunner,
+			nil,
+			ghworkflow.Step(tests.Name()+"-"+arch).SetMakeStep(tests.Name()+"-"+arch),
+		)
+	}
+
+	return nil
+}
+
+// compileShardsGitHubWorkflow runs the shards as the matrix job, the coverage of the shards is merged in the unit-tests job.
+func (tests *UnitTests) compileShardsGitHubWorkflow(output *ghworkflow.Output) error {
+	shardJob := tests.Name() + "-shard"
+	shardName := tests.Name() + "-shard-${{ matrix.shard }}"
+	shardCoverage := "coverage-" + shardName
+
+	matrix := &ghworkflow.StrategyMatrix{}
+
+	for shard := 1; shard <= tests.shards(); shard++ {
+		matrix.Include = append(matrix.Include, map[string]string{"shard": strconv.Itoa(shard)})
+	}
+
+	failFast := false
+
+	steps := append(ghworkflow.DefaultSteps(),
+		ghworkflow.Step(shardName).SetMakeStep(shardName),
+		ghworkflow.Step("save-"+shardCoverage).
+			SetUsesWithComment(
+				"actions/upload-artifact@"+config.UploadArtifactActionRef,
+				"version: "+config.UploadArtifactActionVersion,
+			).
+			SetWith("name", shardCoverage).
+			SetWith("path", filepath.Join(tests.meta.ArtifactsPath, shardCoverage+".txt")).
+			SetWith("retention-days", "5"),
+	)
+
+	if tests.JUnit {
+		steps = append(steps, ghworkflow.TestReportSteps(shardName+"-junit", filepath.Join(tests.meta.ArtifactsPath, "junit-"+shardName+".xml"))...)
+	}
+
+	output.AddJob(shardJob, false, &ghworkflow.Job{
+		RunsOn: ghworkflow.NewRunsOnGroupLabel(ghworkflow.GenericRunner, ""),
+		If:     "github.event_name == 'pull_request'",
+		Strategy: &ghworkflow.Strategy{
+			FailFast: &failFast,
+			Matrix:   matrix,
+		},
+		Needs: []string{ghworkflow.DefaultJobName},
+		Steps: steps,
+	}, nil)
+
+	if tests.meta.SOPSEnabled {
+		output.AddStepAfter(shardJob, "setup-buildx", ghworkflow.SOPSSteps()...)
+	}
+
+	output.AddStepInParallelJob(
+		"unit-tests",
+		ghworkflow.GenericRunner,
+		nil,
+		ghworkflow.Step("Download "+tests.Name()+" shards coverage").
+			SetUsesWithComment(
+				"actions/download-artifact@"+config.DownloadArtifactActionRef,
+				"version: "+config.DownloadArtifactActionVersion,
+			).
+			SetWith("pattern", "coverage-"+tests.Name()+"-shard-*").
+			SetWith("merge-multiple", "true").
+			SetWith("path", tests.meta.ArtifactsPath),
+		ghworkflow.Step("Merge "+tests.Name()+" shards coverage").
+			SetCommand(fmt.Sprintf(coverageMergeScript,
+				filepath.Join(tests.meta.ArtifactsPath, "coverage-"+tests.Name()+"-shard-*.txt"),
+				filepath.Join(tests.meta.ArtifactsPath, "coverage-"+tests.Name()+".txt"),
+			)),
+	)
+
+	output.AddJobNeeds("unit-tests", shardJob)
+
+	return nil
+}
+
+// CompileGitLabCI implements gitlabci.Compiler.
+func (tests *UnitTests) CompileGitLabCI(output *gitlabci.Output) error {
+	if tests.shards() > 0 {
+		tests.compileShardsGitLabCI(output)
+	} else {
+		output.AddStepInParallelJob(
+			"unit-tests",
+			nil,
+			gitlabci.MakeStep(tests.Name()),
+		)
+
+		if tests.JUnit {
+			output.AddTestReports("unit-tests", filepath.Join(tests.meta.ArtifactsPath, "junit-"+tests.Name()+".xml"))
+		}
+	}
+
+	for _, variant := range tests.Variants {
+		name := tests.variantName(variant)
+
+		output.AddStepInParallelJob(
+			variant.jobName(),
+			nil,
+			gitlabci.MakeStep(name),
+		)
+
+		if variant.Coverage && tests.JUnit {
+			output.AddTestReports(variant.jobName(), filepath.Join(tests.meta.ArtifactsPath, "junit-"+name+".xml"))
+		}
+	}
+
+	if tests.RunFIPS {
+		output.AddStepInParallelJob(
+			"unit-tests",
+			nil,
+			gitlabci.MakeStep(tests.Name()+"-fips"),
+		)
+	}
+
+	for _, arch := range tests.crossArchs() {
+		output.AddStepInParallelJob(
+			"unit-tests",
+			nil,
+			gitlabci.MakeStep(tests.Name()+"-"+arch),
+		)
+	}
+
 	return nil
 }
+
+// compileShardsGitLabCI runs the shards as the parallel job, the coverage of the shards is merged in the unit-tests job.
+func (tests *UnitTests) compileShardsGitLabCI(output *gitlabci.Output) {
+	shardJob := tests.Name() + "-shard"
+	shardName := tests.Name() + "-shard-${CI_NODE_INDEX}"
+
+	output.AddStepInParallelJob(shardJob, nil, gitlabci.MakeStep(shardName))
+	output.AddArtifacts(shardJob, "", filepath.Join(tests.meta.ArtifactsPath, "coverage-"+tests.Name()+"-shard-*.txt"))
+	output.SetParallel(shardJob, tests.shards())
+
+	if tests.JUnit {
+		output.AddTestReports(shardJob, filepath.Join(tests.meta.ArtifactsPath, "junit-"+tests.Name()+"-shard-*.xml"))
+	}
+
+	output.AddStepInParallelJob(
+		"unit-tests",
+		nil,
+		gitlabci.Command(fmt.Sprintf(coverageMergeScript,
+			filepath.Join(tests.meta.ArtifactsPath, "coverage-"+tests.Name()+"-shard-*.txt"),
+			filepath.Join(tests.meta.ArtifactsPath, "coverage-"+tests.Name()+".txt"),
+		)),
+	)
+
+	output.AddJobNeeds("unit-tests", shardJob)
+}
@@ -5,14 +5,18 @@
 package golang_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/siderolabs/kres/internal/config"
 	"github.com/siderolabs/kres/internal/output/dockerfile"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/golang"
+	"github.com/siderolabs/kres/internal/project/meta"
 )
 
 func TestUnitTestsInterfaces(t *testing.T) {
@@ -20,3 +24,269 @@ func TestUnitTestsInterfaces(t *testing.T) {
 	assert.Implements(t, (*makefile.Compiler)(nil), new(golang.UnitTests))
 	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(golang.UnitTests))
 }
+
+func TestUnitTestsCrossArch(t *testing.T) {
+	options := &meta.Options{
+		Platforms: []string{"linux/amd64", "linux/arm64", "darwin/arm64"},
+	}
+
+	tests := golang.NewUnitTests(options, ".")
+	tests.RunCrossArch = true
+
+	output := dockerfile.NewOutput()
+	output.Stage("base").From("scratch")
+
+	require.NoError(t, tests.CompileDockerfile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Dockerfile", &buf))
+
+	generated := buf.String()
+
+	assert.Contains(t, generated, "FROM base AS unit-tests-arm64")
+	assert.Contains(t, generated, "COPY --from=docker.io/tonistiigi/binfmt:"+config.BinfmtImageVersion+" /usr/bin/qemu-aarch64 /usr/bin/qemu-aarch64")
+	assert.Contains(t, generated, "CGO_ENABLED=0 GOARCH=arm64 go test -exec qemu-aarch64 ${TESTPKGS}")
+	assert.NotContains(t, generated, "unit-tests-amd64")
+	assert.NotContains(t, generated, "unit-tests-darwin")
+
+	makefileOutput := makefile.NewOutput()
+
+	require.NoError(t, tests.CompileMakefile(makefileOutput))
+
+	buf.Reset()
+
+	require.NoError(t, makefileOutput.GenerateFile("Makefile", &buf))
+	assert.Contains(t, buf.String(), "unit-tests-arm64:")
+}
+
+func TestUnitTestsJUnit(t *testing.T) {
+	options := &meta.Options{
+		ArtifactsPath: "_out",
+		BinPath:       "/bin",
+		CachePath:     "/root/.cache",
+		GoPath:        "/go",
+	}
+
+	tests := golang.NewUnitTests(options, ".")
+	tests.JUnit = true
+
+	require.NoError(t, tests.AfterLoad())
+	assert.Contains(t, options.BuildArgs, "GOTESTSUM_VERSION")
+
+	output := dockerfile.NewOutput()
+	output.Stage("base").From("scratch")
+
+	require.NoError(t, tests.CompileDockerfile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Dockerfile", &buf))
+
+	generated := buf.String()
+
+	assert.Contains(t, generated, "go install gotest.tools/gotestsum@${GOTESTSUM_VERSION}")
+	assert.Contains(t, generated, "gotestsum --format standard-quiet --junitfile /test-reports/junit-unit-tests.xml -- -covermode=atomic")
+	assert.Contains(t, generated, "|| touch coverage.txt /test-reports/unit-tests-failed")
+	assert.Contains(t, generated, "COPY --from=unit-tests-run /test-reports /\n")
+
+	makefileOutput := makefile.NewOutput()
+
+	require.NoError(t, tests.CompileMakefile(makefileOutput))
+
+	buf.Reset()
+
+	require.NoError(t, makefileOutput.GenerateFile("Makefile", &buf))
+	assert.Contains(t, buf.String(), "GOTESTSUM_VERSION ?= "+config.GoTestSumVersion+"\n")
+	assert.Contains(t, buf.String(),
+		"\t@rm -f $(ARTIFACTS)/unit-tests-failed\n\t@$(MAKE) local-$@ DEST=$(ARTIFACTS) TARGET_ARGS=\"--no-cache-filter=unit-tests-run\"\n\t@test ! -f $(ARTIFACTS)/unit-tests-failed\n")
+
+	workflow := ghworkflow.NewOutput("main", true, false, "")
+	workflow.SetRunnerGroup(ghworkflow.GenericRunner)
+
+	require.NoError(t, tests.CompileGitHubWorkflow(workflow))
+
+	buf.Reset()
+
+	require.NoError(t, workflow.GenerateFile(ghworkflow.CiWorkflow, &buf))
+	assert.Contains(t, buf.String(), "name: unit-tests-junit\n")
+	assert.Contains(t, buf.String(), "- name: test-summary\n        if: always()\n")
+	assert.Contains(t, buf.String(), `for report in "_out/junit-unit-tests.xml"; do`)
+}
+
+func TestUnitTestsShards(t *testing.T) {
+	options := &meta.Options{
+		ArtifactsPath: "_out",
+		CachePath:     "/root/.cache",
+		GoPath:        "/go",
+	}
+
+	tests := golang.NewUnitTests(options, ".")
+	tests.Shards = 2
+
+	output := dockerfile.NewOutput()
+	output.Stage("base").From("scratch")
+
+	require.NoError(t, tests.CompileDockerfile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Dockerfile", &buf))
+
+	generated := buf.String()
+
+	assert.Contains(t, generated, "FROM base AS unit-tests-shard-1-run\n")
+	assert.Contains(t, generated, `SHARDPKGS="$(go list ${TESTPKGS} | awk -v shard=2 -v shards=2 '(NR - 1) % shards + 1 == shard')"`)
+	assert.Contains(t, generated, "-coverpkg=${TESTPKGS} ${SHARDPKGS}; fi\n")
+	assert.Contains(t, generated, "COPY --from=unit-tests-shard-2-run /src/coverage.txt /coverage-unit-tests-shard-2.txt\n")
+	assert.NotContains(t, generated, "unit-tests-shard-3")
+
+	makefileOutput := makefile.NewOutput()
+
+	require.NoError(t, tests.CompileMakefile(makefileOutput))
+
+	buf.Reset()
+
+	require.NoError(t, makefileOutput.GenerateFile("Makefile", &buf))
+	assert.Contains(t, buf.String(), "unit-tests-shard-1:")
+	assert.Contains(t, buf.String(), "unit-tests-shard-2:")
+
+	workflow := ghworkflow.NewOutput("main", true, false, "")
+	workflow.SetRunnerGroup(ghworkflow.GenericRunner)
+
+	require.NoError(t, tests.CompileGitHubWorkflow(workflow))
+
+	buf.Reset()
+
+	require.NoError(t, workflow.GenerateFile(ghworkflow.CiWorkflow, &buf))
+	assert.Contains(t, buf.String(), "  unit-tests-shard:\n")
+	assert.Contains(t, buf.String(), "- shard: \"2\"\n")
+	assert.Contains(t, buf.String(), "make unit-tests-shard-${{ matrix.shard }}\n")
+	assert.Contains(t, buf.String(), "      - default\n      - unit-tests-shard\n")
+	assert.Contains(t, buf.String(), "_out/coverage-unit-tests-shard-*.txt > _out/coverage-unit-tests.txt\n")
+	assert.NotContains(t, buf.String(), "make unit-tests\n")
+}
+
+func TestUnitTestsVariants(t *testing.T) {
+	options := &meta.Options{
+		ArtifactsPath: "_out",
+		CachePath:     "/root/.cache",
+		GoPath:        "/go",
+	}
+
+	tests := golang.NewUnitTests(options, ".")
+	tests.Variants = []golang.UnitTestsVariant{
+		{
+			Name: "race",
+			Race: true,
+		},
+		{
+			Name:     "shuffle",
+			Shuffle:  "on",
+			Coverage: true,
+			Job:      true,
+		},
+		{
+			Name:         "greenteagc",
+			GoExperiment: "greenteagc",
+		},
+	}
+
+	require.NoError(t, tests.AfterLoad())
+
+	output := dockerfile.NewOutput()
+	output.Stage("base").From("scratch")
+
+	require.NoError(t, tests.CompileDockerfile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Dockerfile", &buf))
+
+	generated := buf.String()
+
+	assert.Contains(t, generated, "CGO_ENABLED=1 go test -race ${TESTPKGS}\n")
+	assert.Contains(t, generated, "go test -shuffle=on -covermode=atomic -coverprofile=coverage.txt -coverpkg=${TESTPKGS} ${TESTPKGS}\n")
+	assert.Contains(t, generated, "COPY --from=unit-tests-shuffle-run /src/coverage.txt /coverage-unit-tests-shuffle.txt\n")
+	assert.Contains(t, generated, "# runs unit-tests with GOEXPERIMENT=greenteagc\nFROM base AS unit-tests-greenteagc\n")
+	assert.Contains(t, generated, "GOEXPERIMENT=greenteagc go test ${TESTPKGS}\n")
+
+	makefileOutput := makefile.NewOutput()
+
+	require.NoError(t, tests.CompileMakefile(makefileOutput))
+
+	buf.Reset()
+
+	require.NoError(t, makefileOutput.GenerateFile("Makefile", &buf))
+	assert.Contains(t, buf.String(), "unit-tests-race:  ## Performs unit tests with race detection enabled.\n\t@$(MAKE) target-$@\n")
+	assert.Contains(t, buf.String(), "unit-tests-shuffle:  ## Performs unit tests with -shuffle=on.\n\t@$(MAKE) local-$@ DEST=$(ARTIFACTS)\n")
+
+	workflow := ghworkflow.NewOutput("main", true, false, "")
+	workflow.SetRunnerGroup(ghworkflow.GenericRunner)
+
+	require.NoError(t, tests.CompileGitHubWorkflow(workflow))
+
+	buf.Reset()
+
+	require.NoError(t, workflow.GenerateFile(ghworkflow.CiWorkflow, &buf))
+	assert.Contains(t, buf.String(), "  unit-tests-shuffle:\n")
+	assert.Contains(t, buf.String(), "make unit-tests-greenteagc\n")
+
+	var discovered []string
+
+	tests.DiscoverCoverage(func(jobName, flags string, inputs ...string) {
+		discovered = append(discovered, jobName, flags)
+		discovered = append(discovered, inputs...)
+	})
+
+	assert.Equal(t, []string{
+		"unit-tests", "unit-tests", "coverage-unit-tests.txt",
+		"unit-tests-shuffle", "unit-tests-shuffle", "coverage-unit-tests-shuffle.txt",
+	}, discovered)
+}
+
+func TestUnitTestsVariantsJUnit(t *testing.T) {
+	options := &meta.Options{
+		BinPath:   "/bin",
+		CachePath: "/root/.cache",
+		GoPath:    "/go",
+	}
+
+	tests := golang.NewUnitTests(options, ".")
+	tests.JUnit = true
+	tests.Variants = []golang.UnitTestsVariant{
+		{
+			Name:         "race",
+			Race:         true,
+			GoExperiment: "greenteagc",
+			Coverage:     true,
+		},
+	}
+
+	output := dockerfile.NewOutput()
+	output.Stage("base").From("scratch")
+
+	require.NoError(t, tests.CompileDockerfile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Dockerfile", &buf))
+
+	generated := buf.String()
+
+	// the environment applies to the whole script running gotestsum
+	assert.Contains(t, generated, "ARG TESTPKGS\nENV CGO_ENABLED=1\nENV GOEXPERIMENT=greenteagc\nRUN --mount=")
+	assert.Contains(t, generated, "/tmp mkdir -p /test-reports \\\n\t&& gotestsum --format standard-quiet --junitfile /test-reports/junit-unit-tests-race.xml -- -race -covermode=atomic")
+	assert.NotContains(t, generated, "CGO_ENABLED=1 mkdir")
+}
+
+func TestUnitTestsVariantsValidation(t *testing.T) {
+	tests := golang.NewUnitTests(&meta.Options{}, ".")
+	tests.Variants = []golang.UnitTestsVariant{{Name: "race", Race: true}, {Name: "race"}}
+
+	require.EqualError(t, tests.AfterLoad(), `unit-tests: duplicate variant "race"`)
+
+	tests.Variants = []golang.UnitTestsVariant{{Race: true}}
+
+	require.EqualError(t, tests.AfterLoad(), "unit-tests: variant name is required")
+}
@@ -0,0 +1,26 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package golang
+
+import (
+	"path"
+	"path/filepath"
+
+	"github.com/siderolabs/kres/internal/project/meta"
+)
+
+// workspacePackages returns the package patterns covering all modules of the Go workspace.
+//
+// In workspace mode `./...` matches only the packages of the module in the current directory,
+// so each module used by go.work is listed explicitly.
+func workspacePackages(meta *meta.Options) []string {
+	patterns := make([]string, 0, len(meta.GoWorkspaceModules))
+
+	for _, dir := range meta.GoWorkspaceModules {
+		patterns = append(patterns, "./"+path.Join(filepath.ToSlash(dir), "..."))
+	}
+
+	return patterns
+}
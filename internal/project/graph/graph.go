@@ -0,0 +1,146 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+// Package graph provides a printable view of the project DAG.
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/siderolabs/kres/internal/config"
+	"github.com/siderolabs/kres/internal/dag"
+)
+
+// Node is a single node of the project DAG.
+type Node struct {
+	id string
+
+	Name      string   `json:"name"`
+	Type      string   `json:"type"`
+	Compilers []string `json:"compilers"`
+	Inputs    []string `json:"inputs"`
+
+	inputIDs []string
+}
+
+// Graph is a printable view of the project DAG.
+type Graph struct {
+	Targets []string `json:"targets"`
+	Nodes   []*Node  `json:"nodes"`
+}
+
+// Build walks the DAG and records every node with the list of compiler interfaces it implements.
+//
+// Nodes are listed in the walk order, i.e. inputs come before the nodes depending on them.
+func Build(g dag.Graph, compilers ...reflect.Type) (*Graph, error) {
+	graph := &Graph{}
+	ids := map[dag.Node]string{}
+
+	if err := dag.Walk(g, func(node dag.Node) error {
+		typ := reflect.TypeOf(node)
+
+		n := &Node{
+			id:        fmt.Sprintf("n%d", len(graph.Nodes)),
+			Name:      node.Name(),
+			Type:      config.Kind(typ),
+			Compilers: []string{},
+			Inputs:    []string{},
+		}
+
+		for _, compiler := range compilers {
+			if typ.Implements(compiler) {
+				n.Compilers = append(n.Compilers, compiler.String())
+			}
+		}
+
+		// inputs are always walked before the node itself
+		for _, input := range node.Inputs() {
+			n.Inputs = append(n.Inputs, input.Name())
+			n.inputIDs = append(n.inputIDs, ids[input])
+		}
+
+		ids[node] = n.id
+		graph.Nodes = append(graph.Nodes, n)
+
+		return nil
+	}, nil, -1); err != nil {
+		return nil, err
+	}
+
+	for _, target := range g.Targets() {
+		graph.Targets = append(graph.Targets, target.Name())
+	}
+
+	return graph, nil
+}
+
+// WriteJSON writes the graph as JSON.
+func (graph *Graph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(graph)
+}
+
+// WriteDOT writes the graph in Graphviz DOT format.
+func (graph *Graph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+
+	sb.WriteString("digraph kres {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box];\n")
+
+	for _, node := range graph.Nodes {
+		label := node.Name + `\n` + node.Type
+
+		if len(node.Compilers) > 0 {
+			label += `\n` + strings.Join(node.Compilers, `\n`)
+		}
+
+		fmt.Fprintf(&sb, "  %s [label=\"%s\"];\n", node.id, strings.ReplaceAll(label, `"`, `\"`))
+	}
+
+	for _, node := range graph.Nodes {
+		for _, inputID := range node.inputIDs {
+			fmt.Fprintf(&sb, "  %s -> %s;\n", inputID, node.id)
+		}
+	}
+
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+// WriteMermaid writes the graph as Mermaid flowchart.
+func (graph *Graph) WriteMermaid(w io.Writer) error {
+	var sb strings.Builder
+
+	sb.WriteString("flowchart LR\n")
+
+	for _, node := range graph.Nodes {
+		label := node.Name + "<br/>" + node.Type
+
+		if len(node.Compilers) > 0 {
+			label += "<br/><small>" + strings.Join(node.Compilers, "<br/>") + "</small>"
+		}
+
+		fmt.Fprintf(&sb, "  %s[\"%s\"]\n", node.id, strings.ReplaceAll(label, `"`, "#quot;"))
+	}
+
+	for _, node := range graph.Nodes {
+		for _, inputID := range node.inputIDs {
+			fmt.Fprintf(&sb, "  %s --> %s\n", inputID, node.id)
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
@@ -0,0 +1,98 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package graph_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/siderolabs/kres/internal/dag"
+	"github.com/siderolabs/kres/internal/output/makefile"
+	"github.com/siderolabs/kres/internal/project/graph"
+)
+
+type Plain struct {
+	dag.BaseNode
+}
+
+type Target struct {
+	dag.BaseNode
+}
+
+func (*Target) CompileMakefile(*makefile.Output) error {
+	return nil
+}
+
+func buildGraph(t *testing.T) *graph.Graph {
+	t.Helper()
+
+	base := &Plain{BaseNode: dag.NewBaseNode("base")}
+	lint := &Target{BaseNode: dag.NewBaseNode("lint")}
+	all := &Target{BaseNode: dag.NewBaseNode("all")}
+
+	lint.AddInput(base)
+	all.AddInput(base, lint)
+
+	var contents dag.BaseGraph
+
+	contents.AddTarget(all)
+
+	g, err := graph.Build(&contents, reflect.TypeFor[makefile.Compiler]())
+	require.NoError(t, err)
+
+	return g
+}
+
+func TestDOT(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, buildGraph(t).WriteDOT(&buf))
+
+	assert.Equal(t, `digraph kres {
+  rankdir=LR;
+  node [shape=box];
+  n0 [label="base\ngraph_test.Plain"];
+  n1 [label="lint\ngraph_test.Target\nmakefile.Compiler"];
+  n2 [label="all\ngraph_test.Target\nmakefile.Compiler"];
+  n0 -> n1;
+  n0 -> n2;
+  n1 -> n2;
+}
+`, buf.String())
+}
+
+func TestMermaid(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, buildGraph(t).WriteMermaid(&buf))
+
+	assert.Equal(t, `flowchart LR
+  n0["base<br/>graph_test.Plain"]
+  n1["lint<br/>graph_test.Target<br/><small>makefile.Compiler</small>"]
+  n2["all<br/>graph_test.Target<br/><small>makefile.Compiler</small>"]
+  n0 --> n1
+  n0 --> n2
+  n1 --> n2
+`, buf.String())
+}
+
+func TestJSON(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, buildGraph(t).WriteJSON(&buf))
+
+	assert.JSONEq(t, `{
+		"targets": ["all"],
+		"nodes": [
+			{"name": "base", "type": "graph_test.Plain", "compilers": [], "inputs": []},
+			{"name": "lint", "type": "graph_test.Target", "compilers": ["makefile.Compiler"], "inputs": ["base"]},
+			{"name": "all", "type": "graph_test.Target", "compilers": ["makefile.Compiler"], "inputs": ["base", "lint"]}
+		]
+	}`, buf.String())
+}
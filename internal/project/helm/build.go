@@ -17,6 +17,7 @@ import (
 	"github.com/siderolabs/kres/internal/output/dockerfile/step"
 	"github.com/siderolabs/kres/internal/output/dockerignore"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -275,6 +276,16 @@ func (helm *Build) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 	// Add steps for unit tests
 	jobSteps = append(jobSteps, []*ghworkflow.JobStep{unittestPluginInstallStep, unittestStep}...)
 
+	if helm.meta.HelmJUnit {
+		for _, reportStep := range ghworkflow.TestReportSteps("helm-unittest-report", filepath.Join(helm.meta.ArtifactsPath, "helm-unittest-report.xml")) {
+			if err := reportStep.SetConditions("on-pull-request"); err != nil {
+				return err
+			}
+
+			jobSteps = append(jobSteps, reportStep)
+		}
+	}
+
 	// Add steps for schema generation and docs generation if enforced
 	if helm.meta.EnforceHelmSchema || helm.meta.EnforceHelmDocs {
 		var helmSteps []*ghworkflow.JobStep
@@ -325,3 +336,80 @@ func (helm *Build) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 
 	return nil
 }
+
+// CompileGitLabCI implements gitlabci.Compiler.
+func (helm *Build) CompileGitLabCI(output *gitlabci.Output) error {
+	// When chartVersionMajor is set, only release stable (non-prerelease) charts.
+	releaseCondition := "only-on-tag"
+	if helm.meta.ChartVersionMajor != nil {
+		releaseCondition = "only-on-stable-tag"
+	}
+
+	job := &gitlabci.Job{
+		Stage: gitlabci.StageTest,
+		// cosign signs the chart keyless with the job identity
+		IDTokens: map[string]gitlabci.IDToken{
+			"SIGSTORE_ID_TOKEN": {Aud: "sigstore"},
+		},
+	}
+
+	if err := job.SetConditions("on-pull-request"); err != nil {
+		return err
+	}
+
+	job.Rules[0].Changes = []string{filepath.Dir(helm.meta.HelmChartDir) + "/**/*"}
+
+	if err := job.SetConditions(releaseCondition); err != nil {
+		return err
+	}
+
+	checkSteps := []*gitlabci.Step{
+		gitlabci.Command(fmt.Sprintf("helm lint %s", helm.meta.HelmChartDir)),
+		gitlabci.Command(fmt.Sprintf(
+			"helm template %s %s %s",
+			strings.Join(helm.meta.HelmTemplateFlags, " "),
+			filepath.Base(helm.meta.HelmChartDir),
+			helm.meta.HelmChartDir,
+		)),
+		gitlabci.MakeStep("helm-plugin-install"),
+		gitlabci.MakeStep("chart-unittest"),
+	}
+
+	if helm.meta.EnforceHelmSchema {
+		checkSteps = append(checkSteps, gitlabci.MakeStep("chart-gen-schema"))
+	}
+
+	if helm.meta.EnforceHelmDocs {
+		checkSteps = append(checkSteps, gitlabci.MakeStep("helm-docs"))
+	}
+
+	for _, checkStep := range checkSteps {
+		if err := checkStep.SetConditions("on-pull-request"); err != nil {
+			return err
+		}
+	}
+
+	releaseSteps := []*gitlabci.Step{
+		gitlabci.Command(`helm registry login -u "${CI_REGISTRY_USER}" -p "${CI_REGISTRY_PASSWORD}" "${CI_REGISTRY}"`),
+		gitlabci.MakeStep("helm-release"),
+	}
+
+	for _, releaseStep := range releaseSteps {
+		if err := releaseStep.SetConditions(releaseCondition); err != nil {
+			return err
+		}
+	}
+
+	output.AddJob("helm", job)
+	output.AddStep("helm", slices.Concat(
+		[]*gitlabci.Step{gitlabci.Command("apk add --no-cache helm cosign")},
+		checkSteps,
+		releaseSteps,
+	)...)
+
+	if helm.meta.HelmJUnit {
+		output.AddTestReports("helm", filepath.Join(helm.meta.ArtifactsPath, "helm-unittest-report.xml"))
+	}
+
+	return nil
+}
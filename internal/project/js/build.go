@@ -12,6 +12,7 @@ import (
 	"github.com/siderolabs/kres/internal/output/dockerfile"
 	"github.com/siderolabs/kres/internal/output/dockerfile/step"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/output/template"
 	"github.com/siderolabs/kres/internal/project/js/templates"
@@ -97,6 +98,13 @@ func (build *Build) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 	return nil
 }
 
+// CompileGitLabCI implements gitlabci.Compiler.
+func (build *Build) CompileGitLabCI(output *gitlabci.Output) error {
+	output.AddStep(gitlabci.DefaultJobName, gitlabci.MakeStep(build.Name()))
+
+	return nil
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (build *Build) CompileMakefile(output *makefile.Output) error {
 	output.VariableGroup(makefile.VariableGroupCommon).
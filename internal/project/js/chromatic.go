@@ -11,6 +11,7 @@ import (
 	"github.com/siderolabs/kres/internal/config"
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
 
@@ -131,3 +132,13 @@ func (c *Chromatic) CompileGitHubWorkflow(o *ghworkflow.Output) error {
 
 	return nil
 }
+
+// CompileGitLabCI implements gitlabci.Compiler.
+func (c *Chromatic) CompileGitLabCI(*gitlabci.Output) error {
+	if !c.Enabled {
+		return nil
+	}
+
+	// Storybook snapshots are published with the GitHub action reporting the results to the pull requests
+	return fmt.Errorf("chromatic is not supported with %s CI provider", meta.CIProviderGitLab)
+}
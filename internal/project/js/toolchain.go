@@ -14,6 +14,7 @@ import (
 	"github.com/siderolabs/kres/internal/output/dockerfile/step"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
 	"github.com/siderolabs/kres/internal/output/gitignore"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/output/template"
 	"github.com/siderolabs/kres/internal/project/common"
@@ -93,6 +94,13 @@ func (toolchain *Toolchain) CompileGitHubWorkflow(output *ghworkflow.Output) err
 	return nil
 }
 
+// CompileGitLabCI implements gitlabci.Compiler.
+func (toolchain *Toolchain) CompileGitLabCI(output *gitlabci.Output) error {
+	output.AddStep(gitlabci.DefaultJobName, gitlabci.MakeStep("js"))
+
+	return nil
+}
+
 // CompileDockerfile implements dockerfile.Compiler.
 func (toolchain *Toolchain) CompileDockerfile(output *dockerfile.Output) error {
 	output.Arg(step.Arg("JS_TOOLCHAIN"))
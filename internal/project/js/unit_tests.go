@@ -5,10 +5,14 @@
 package js
 
 import (
+	"fmt"
+	"path/filepath"
+
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/output/dockerfile"
 	"github.com/siderolabs/kres/internal/output/dockerfile/step"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -17,6 +21,15 @@ import (
 type UnitTests struct {
 	meta *meta.Options
 	dag.BaseNode
+
+	// JUnitReporterArgs are the arguments of `npm test` making the test runner write the JUnit XML report,
+	// defaults to the vitest JUnit reporter.
+	JUnitReporterArgs string `yaml:"junitReporterArgs"`
+	// JUnitOutput is the path of the JUnit XML report written by the reporter relative to the JS project root.
+	JUnitOutput string `yaml:"junitOutput"`
+	// JUnit exports the JUnit XML report of the unit-tests to $(ARTIFACTS)/junit-<name>.xml,
+	// the test run is not cached by the build then, as the failed runs would be cached too.
+	JUnit bool `yaml:"junit"`
 }
 
 // NewUnitTests initializes UnitTests.
@@ -24,17 +37,46 @@ func NewUnitTests(meta *meta.Options, name string) *UnitTests {
 	return &UnitTests{
 		BaseNode: dag.NewBaseNode(name),
 		meta:     meta,
+
+		JUnitReporterArgs: "--reporter=default --reporter=junit --outputFile.junit=junit.xml",
+		JUnitOutput:       "junit.xml",
 	}
 }
 
+func (tests *UnitTests) junitFailedMarker() string {
+	return tests.Name() + "-failed"
+}
+
 // CompileDockerfile implements dockerfile.Compiler.
 func (tests *UnitTests) CompileDockerfile(output *dockerfile.Output) error {
-	output.Stage(tests.Name()).
+	if !tests.JUnit {
+		output.Stage(tests.Name()).
+			Description("runs js unit-tests").
+			From("js").
+			Step(step.Script(`npm test`).
+				Env("CI", "true"))
+
+		return nil
+	}
+
+	testRun := tests.Name() + "-run"
+
+	// the test failure is recorded, so that the report is exported even if the tests fail
+	output.Stage(testRun).
 		Description("runs js unit-tests").
 		From("js").
-		Step(step.Script(`npm test`).
+		Step(step.Script(fmt.Sprintf(
+			`mkdir -p /test-reports \
+	&& { npm test -- %s || touch /test-reports/%s; } \
+	&& cp %s /test-reports/junit-%s.xml`,
+			tests.JUnitReporterArgs, tests.junitFailedMarker(), tests.JUnitOutput, tests.Name(),
+		)).
 			Env("CI", "true"))
 
+	output.Stage(tests.Name()).
+		From("scratch").
+		Step(step.Copy("/test-reports", "/").From(testRun))
+
 	return nil
 }
 
@@ -43,9 +85,23 @@ func (tests *UnitTests) CompileMakefile(output *makefile.Output) error {
 	output.VariableGroup(makefile.VariableGroupCommon).
 		Variable(makefile.OverridableVariable("TESTPKGS", "./..."))
 
+	script := []string{"@$(MAKE) target-$@"}
+
+	if tests.JUnit {
+		// the report is exported even if the tests fail, the failure is signaled with the marker file
+		marker := "$(ARTIFACTS)/" + tests.junitFailedMarker()
+
+		// the failed run is exported successfully, so it's excluded from the build cache not to report the cached failure
+		script = []string{
+			"@rm -f " + marker,
+			fmt.Sprintf(`@$(MAKE) local-$@ DEST=$(ARTIFACTS) TARGET_ARGS="--no-cache-filter=%s-run"`, tests.Name()),
+			"@test ! -f " + marker,
+		}
+	}
+
 	output.Target(tests.Name()).
 		Description("Performs unit tests").
-		Script("@$(MAKE) target-$@").
+		Script(script...).
 		Phony()
 
 	return nil
@@ -60,6 +116,15 @@ func (tests *UnitTests) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 		ghworkflow.Step(tests.Name()).SetMakeStep(tests.Name()),
 	)
 
+	if tests.JUnit {
+		output.AddStepInParallelJob(
+			"unit-tests",
+			ghworkflow.GenericRunner,
+			nil,
+			ghworkflow.TestReportSteps(tests.Name()+"-junit", filepath.Join(tests.meta.ArtifactsPath, "junit-"+tests.Name()+".xml"))...,
+		)
+	}
+
 	if tests.meta.SOPSEnabled {
 		output.AddStepAfter(
 			"unit-tests",
@@ -70,3 +135,18 @@ func (tests *UnitTests) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 
 	return nil
 }
+
+// CompileGitLabCI implements gitlabci.Compiler.
+func (tests *UnitTests) CompileGitLabCI(output *gitlabci.Output) error {
+	output.AddStepInParallelJob(
+		"unit-tests",
+		nil,
+		gitlabci.MakeStep(tests.Name()),
+	)
+
+	if tests.JUnit {
+		output.AddTestReports("unit-tests", filepath.Join(tests.meta.ArtifactsPath, "junit-"+tests.Name()+".xml"))
+	}
+
+	return nil
+}
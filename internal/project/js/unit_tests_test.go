@@ -5,14 +5,17 @@
 package js_test
 
 import (
+	"bytes"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/siderolabs/kres/internal/output/dockerfile"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/js"
+	"github.com/siderolabs/kres/internal/project/meta"
 )
 
 func TestUnitTestsInterfaces(t *testing.T) {
@@ -20,3 +23,32 @@ func TestUnitTestsInterfaces(t *testing.T) {
 	assert.Implements(t, (*makefile.Compiler)(nil), new(js.UnitTests))
 	assert.Implements(t, (*ghworkflow.Compiler)(nil), new(js.UnitTests))
 }
+
+func TestUnitTestsJUnit(t *testing.T) {
+	tests := js.NewUnitTests(&meta.Options{ArtifactsPath: "_out"}, "unit-tests-frontend")
+	tests.JUnit = true
+
+	output := dockerfile.NewOutput()
+	output.Stage("js").From("scratch")
+
+	require.NoError(t, tests.CompileDockerfile(output))
+
+	var buf bytes.Buffer
+
+	require.NoError(t, output.GenerateFile("Dockerfile", &buf))
+
+	generated := buf.String()
+
+	assert.Contains(t, generated, "{ npm test -- --reporter=default --reporter=junit --outputFile.junit=junit.xml || touch /test-reports/unit-tests-frontend-failed; }")
+	assert.Contains(t, generated, "&& cp junit.xml /test-reports/junit-unit-tests-frontend.xml")
+	assert.Contains(t, generated, "FROM scratch AS unit-tests-frontend\nCOPY --from=unit-tests-frontend-run /test-reports /\n")
+
+	makefileOutput := makefile.NewOutput()
+
+	require.NoError(t, tests.CompileMakefile(makefileOutput))
+
+	buf.Reset()
+
+	require.NoError(t, makefileOutput.GenerateFile("Makefile", &buf))
+	assert.Contains(t, buf.String(), "\t@$(MAKE) local-$@ DEST=$(ARTIFACTS) TARGET_ARGS=\"--no-cache-filter=unit-tests-frontend-run\"\n\t@test ! -f $(ARTIFACTS)/unit-tests-frontend-failed\n")
+}
@@ -11,6 +11,13 @@ import (
 	"github.com/siderolabs/kres/internal/config"
 )
 
+// CI providers supported by [Options.CIProvider].
+const (
+	CIProviderGitHub  = "github"
+	CIProviderGitLab  = "gitlab"
+	CIProviderForgejo = "forgejo"
+)
+
 // Options for the project.
 type Options struct { //nolint:govet
 	// Config provider.
@@ -63,9 +70,21 @@ type Options struct { //nolint:govet
 	// Commands are top-level binaries to be built.
 	Commands []Command
 
+	// Platforms is the list of GOOS/GOARCH pairs Go commands are built for.
+	Platforms []string
+
 	// GoRootDirectories contans the list of all go.mod root directories.
 	GoRootDirectories []string
 
+	// GoReplaceDirectories are local directories referenced by replace directives of go.mod and go.work files.
+	GoReplaceDirectories []string
+
+	// GoWorkspaceModules are the module directories used by go.work, set if the project is a Go workspace.
+	GoWorkspaceModules []string
+
+	// GoWorkspaceFiles are go.work and go.work.sum files of the Go workspace.
+	GoWorkspaceFiles []string
+
 	// BuildArgs passed down to Dockerfiles.
 	BuildArgs BuildArgs
 
@@ -87,6 +106,15 @@ type Options struct { //nolint:govet
 	// ArtifactsPath binary output path.
 	ArtifactsPath string
 
+	// CIProvider is the CI system to generate the configuration for: github (default), gitlab or forgejo.
+	CIProvider string
+
+	// ForgejoActionsURL is the base URL of the mirror to resolve the actions from with forgejo CI provider.
+	ForgejoActionsURL string
+
+	// ForgejoRunners maps runner groups to Forgejo runner labels.
+	ForgejoRunners map[string]string
+
 	// CompileGithubWorkflowsOnly indicates that only GitHub workflows should be compiled.
 	CompileGithubWorkflowsOnly bool
 
@@ -120,6 +148,9 @@ type Options struct { //nolint:govet
 	// EnforceHelmSchema indicates whether usage of helm schema should be enforced.
 	EnforceHelmSchema bool
 
+	// HelmJUnit indicates whether the helm unit test report should be uploaded and summarized in CI.
+	HelmJUnit bool
+
 	// ChartVersionMajor, when set (non-nil), enables automatic chart version management.
 	ChartVersionMajor *uint
 }
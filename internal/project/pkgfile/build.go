@@ -7,6 +7,7 @@ package pkgfile
 
 import (
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/output/dockerignore"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/output/renovate"
 	"github.com/siderolabs/kres/internal/project/meta"
@@ -327,6 +329,56 @@ func (pkgfile *Build) CompileGitHubWorkflow(output *ghworkflow.Output) error {
 	return nil
 }
 
+// CompileGitLabCI implements gitlabci.Compiler.
+func (pkgfile *Build) CompileGitLabCI(output *gitlabci.Output) error {
+	buildStep := gitlabci.MakeStep("")
+
+	if err := buildStep.SetConditions("on-pull-request"); err != nil {
+		return err
+	}
+
+	output.AddStep(gitlabci.DefaultJobName, buildStep)
+
+	loginStep := gitlabci.LoginStep()
+
+	if err := loginStep.SetConditions("except-pull-request"); err != nil {
+		return err
+	}
+
+	pushStep := gitlabci.MakeStep("", "PUSH=true")
+
+	if err := pushStep.SetConditions("except-pull-request"); err != nil {
+		return err
+	}
+
+	steps := []*gitlabci.Step{
+		loginStep,
+		pushStep,
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(pkgfile.AdditionalTargets)) {
+		buildStep := gitlabci.MakeStep(name)
+
+		if err := buildStep.SetConditions("on-pull-request"); err != nil {
+			return err
+		}
+
+		output.AddStep(gitlabci.DefaultJobName, buildStep)
+
+		pushStep := gitlabci.MakeStep(name, "PUSH=true")
+
+		if err := pushStep.SetConditions("except-pull-request"); err != nil {
+			return err
+		}
+
+		steps = append(steps, pushStep)
+	}
+
+	output.AddStep(gitlabci.DefaultJobName, steps...)
+
+	return nil
+}
+
 // CompileRenovate implements renovate.Compiler.
 func (pkgfile *Build) CompileRenovate(output *renovate.Output) error {
 	customManagers := []renovate.CustomManager{
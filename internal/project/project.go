@@ -37,10 +37,12 @@ func (project *Contents) CompileTo(out output.Writer, visited map[dag.Node]struc
 }
 
 // LoadConfig walks the tree and loads the config into every node.
+//
+// Config documents which don't match any node are reported as errors.
 func (project *Contents) LoadConfig(config *config.Provider) error {
 	visited := map[dag.Node]struct{}{}
 
-	return dag.Walk(project, func(node dag.Node) error {
+	if err := dag.Walk(project, func(node dag.Node) error {
 		if err := config.Load(node); err != nil {
 			return err
 		}
@@ -52,5 +54,13 @@ func (project *Contents) LoadConfig(config *config.Provider) error {
 		}
 
 		return nil
-	}, visited, -1)
+	}, visited, -1); err != nil {
+		return err
+	}
+
+	if err := config.CheckConsumed(); err != nil {
+		return err
+	}
+
+	return config.CheckMetadata()
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/siderolabs/kres/internal/dag"
 	"github.com/siderolabs/kres/internal/output/codecov"
 	"github.com/siderolabs/kres/internal/output/ghworkflow"
+	"github.com/siderolabs/kres/internal/output/gitlabci"
 	"github.com/siderolabs/kres/internal/output/makefile"
 	"github.com/siderolabs/kres/internal/project/meta"
 )
@@ -48,6 +49,22 @@ func NewCodeCov(meta *meta.Options) *CodeCov {
 	}
 }
 
+// CoverageDiscoverer is implemented by the inputs which export the coverage files depending on the loaded config.
+type CoverageDiscoverer interface {
+	DiscoverCoverage(add func(jobName, flags string, inputs ...string))
+}
+
+// AfterLoad discovers the coverage files of the inputs.
+func (coverage *CodeCov) AfterLoad() error {
+	for _, input := range coverage.Inputs() {
+		if discoverer, ok := input.(CoverageDiscoverer); ok {
+			discoverer.DiscoverCoverage(coverage.AddDiscoveredInputs)
+		}
+	}
+
+	return nil
+}
+
 // AddDiscoveredInputs sets automatically discovered codecov.txt files.
 func (coverage *CodeCov) AddDiscoveredInputs(jobName string, flags string, inputs ...string) {
 	coverage.discoveredPaths[dependentJobs{name: jobName, flags: flags}] = append(coverage.discoveredPaths[dependentJobs{name: jobName, flags: flags}], inputs...)
@@ -89,6 +106,33 @@ func (coverage *CodeCov) CompileGitHubWorkflow(output *ghworkflow.Output) error
 	return nil
 }
 
+// CompileGitLabCI implements gitlabci.Compiler.
+func (coverage *CodeCov) CompileGitLabCI(output *gitlabci.Output) error {
+	if !coverage.Enabled {
+		return nil
+	}
+
+	for job, paths := range coverage.discoveredPaths {
+		upload := []string{"codecov", "upload-process", "--disable-search", "--git-service", "gitlab", "--token", `"${CODECOV_TOKEN}"`, "--flag", job.flags}
+
+		for _, p := range paths {
+			upload = append(upload, "--file", fmt.Sprintf("%s/%s", coverage.meta.ArtifactsPath, p))
+		}
+
+		output.AddStepInParallelJob(
+			job.name,
+			nil,
+			gitlabci.Command(fmt.Sprintf(
+				"wget -qO /usr/local/bin/codecov https://cli.codecov.io/%s/alpine/codecov && chmod +x /usr/local/bin/codecov",
+				config.CodeCovCLIVersion,
+			)),
+			gitlabci.Command(strings.Join(upload, " ")),
+		)
+	}
+
+	return nil
+}
+
 // CompileMakefile implements makefile.Compiler.
 func (coverage *CodeCov) CompileMakefile(_ *makefile.Output) error {
 	return nil